@@ -0,0 +1,156 @@
+// Command harness sends signed fake interactions to the bot's http interactions endpoint for local testing.
+//
+// Generate a key pair and run the bot with the printed public key as ADVANCED_PURGE_PUBLIC_KEY:
+//
+//	go run ./cmd/harness -keygen
+//
+// Then send interactions signed with the printed private key:
+//
+//	go run ./cmd/harness -key <private key> -command "purge history"
+//	go run ./cmd/harness -key <private key> -payload interaction.json
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func main() {
+	var (
+		keygen    = flag.Bool("keygen", false, "generate a key pair and exit")
+		key       = flag.String("key", os.Getenv("ADVANCED_PURGE_HARNESS_KEY"), "hex encoded private key seed to sign with")
+		url       = flag.String("url", "http://localhost:80/interactions/callback", "url of the interactions endpoint")
+		payload   = flag.String("payload", "", "file with the interaction payload to send, - reads stdin")
+		command   = flag.String("command", "", "slash command to send like \"purge history\", a ping is sent otherwise")
+		guildID   = flag.String("guild", "1", "guild ID of fake slash commands")
+		channelID = flag.String("channel", "2", "channel ID of fake slash commands")
+		userID    = flag.String("user", "3", "user ID of fake slash commands")
+		invalid   = flag.Bool("invalid", false, "send an invalid signature")
+	)
+	flag.Parse()
+
+	if *keygen {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("public key:  %s\nprivate key: %s\n", hex.EncodeToString(publicKey), hex.EncodeToString(privateKey.Seed()))
+		return
+	}
+
+	seed, err := hex.DecodeString(*key)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		fail(fmt.Errorf("provide the private key printed by -keygen"))
+	}
+
+	var body []byte
+	switch {
+	case *payload == "-":
+		body, err = io.ReadAll(os.Stdin)
+	case *payload != "":
+		body, err = os.ReadFile(*payload)
+	case *command != "":
+		body, err = json.Marshal(slashCommand(*command, snowflake.MustParse(*guildID), snowflake.MustParse(*channelID), snowflake.MustParse(*userID)))
+	default:
+		body, err = json.Marshal(map[string]any{
+			"id":             snowflake.New(time.Now()),
+			"application_id": snowflake.New(time.Now()),
+			"type":           discord.InteractionTypePing,
+			"token":          "harness",
+			"version":        1,
+		})
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := ed25519.Sign(ed25519.NewKeyFromSeed(seed), append([]byte(timestamp), body...))
+	if *invalid {
+		signature[0] ^= 0xff
+	}
+	request, err := http.NewRequest(http.MethodPost, *url, bytes.NewReader(body))
+	if err != nil {
+		fail(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+	request.Header.Set("X-Signature-Timestamp", timestamp)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		fail(err)
+	}
+	defer response.Body.Close()
+	responseBody, _ := io.ReadAll(response.Body)
+	fmt.Printf("%s\n%s\n", response.Status, bytes.TrimSpace(responseBody))
+}
+
+// slashCommand returns the payload of a slash command like "purge history" run by an administrator. Options are
+// not supported.
+func slashCommand(command string, guildID snowflake.ID, channelID snowflake.ID, userID snowflake.ID) map[string]any {
+	names := strings.Fields(command)
+	var options []map[string]any
+	for i := len(names) - 1; i > 0; i-- {
+		option := map[string]any{
+			"name": names[i],
+			"type": discord.ApplicationCommandOptionTypeSubCommand,
+		}
+		if options != nil {
+			option["type"] = discord.ApplicationCommandOptionTypeSubCommandGroup
+			option["options"] = options
+		}
+		options = []map[string]any{option}
+	}
+	now := time.Now()
+	return map[string]any{
+		"id":             snowflake.New(now),
+		"application_id": snowflake.New(now),
+		"type":           discord.InteractionTypeApplicationCommand,
+		"token":          "harness",
+		"version":        1,
+		"guild_id":       guildID,
+		"channel_id":     channelID,
+		"channel": map[string]any{
+			"id":   channelID,
+			"type": discord.ChannelTypeGuildText,
+			"name": "harness",
+		},
+		"member": map[string]any{
+			"user": map[string]any{
+				"id":       userID,
+				"username": "harness",
+			},
+			"roles":       []snowflake.ID{},
+			"permissions": discord.PermissionAdministrator,
+			"joined_at":   now,
+		},
+		"data": map[string]any{
+			"id":      snowflake.New(now),
+			"name":    names[0],
+			"type":    discord.ApplicationCommandTypeSlash,
+			"options": options,
+		},
+		"locale":          "en-US",
+		"app_permissions": discord.PermissionsAll,
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
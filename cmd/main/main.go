@@ -1,39 +1,205 @@
 package main
 
 import (
+	"advanced-purge/config"
 	"advanced-purge/handlers"
+	"advanced-purge/health"
+	"advanced-purge/i18n"
+	"advanced-purge/metrics"
+	"advanced-purge/purge"
+	"advanced-purge/storage"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/disgoorg/disgo"
 	"github.com/disgoorg/disgo/bot"
 	"github.com/disgoorg/disgo/cache"
 	"github.com/disgoorg/disgo/gateway"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/disgo/httpserver"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
 	"github.com/lmittmann/tint"
 )
 
+const (
+	// restCheckInterval is the interval at which the readiness check calls Discord's REST API.
+	restCheckInterval = 30 * time.Second
+	// interruptTimeout is the time interrupted purges get to report their progress and store their checkpoints.
+	interruptTimeout = 10 * time.Second
+	// closeTimeout is the time the gateway and the http servers get to close.
+	closeTimeout = 5 * time.Second
+)
+
 func main() {
-	logger := tint.NewHandler(os.Stdout, &tint.Options{
-		Level: slog.LevelInfo,
-	})
-	slog.SetDefault(slog.New(logger))
+	cfg, err := config.Load(os.Getenv("ADVANCED_PURGE_CONFIG"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%s\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(newLogHandler(cfg.Log)))
 
 	slog.Info("starting the bot...", slog.String("disgo.version", disgo.Version))
 
-	client, err := disgo.New(os.Getenv("ADVANCED_PURGE_TOKEN"),
-		bot.WithGatewayConfigOpts(gateway.WithIntents(gateway.IntentsNone)),
+	policies, err := purge.LoadPolicies(cfg.Files.Policies)
+	if err != nil {
+		panic(err)
+	}
+
+	store, err := storage.Open(cfg.StoragePath)
+	if err != nil {
+		panic(err)
+	}
+	defer store.Close()
+
+	var (
+		index   *purge.Index
+		intents = gateway.IntentsNone
+	)
+	if cfg.IndexEnabled() {
+		index = purge.NewIndex(cfg.Features.IndexChannels, cfg.Limits.IndexMessages)
+		intents = gateway.IntentGuildMessages
+	}
+
+	var guardConfigs purge.GuardConfigs
+	if cfg.GuardEnabled() {
+		if guardConfigs, err = purge.LoadGuardConfigs(cfg.Files.Guards); err != nil {
+			panic(err)
+		}
+	}
+	if len(guardConfigs) > 0 {
+		// identical message detection needs the message content
+		intents |= gateway.IntentGuildMessages | gateway.IntentMessageContent
+	}
+
+	marks := purge.Marks{
+		Purge: cfg.Marks.Purge,
+		Keep:  cfg.Marks.Keep,
+	}
+
+	var approvals purge.ApprovalConfigs
+	if cfg.Features.Approvals {
+		if approvals, err = purge.LoadApprovalConfigs(cfg.Files.Approvals); err != nil {
+			panic(err)
+		}
+	}
+
+	catalogs, err := i18n.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	h := handlers.NewHandler(store, index, purge.NewGuard(guardConfigs), marks, approvals, policies, catalogs, handlers.Limits{
+		IdleTimeout: cfg.Limits.IdleTimeout,
+		BulkLimit:   cfg.Limits.BulkLimit,
+		MaxCount:    cfg.Limits.MaxCount,
+	})
+	listeners := []bot.EventListener{h}
+	if index != nil {
+		listeners = append(listeners, h.IndexListener())
+	}
+	if len(guardConfigs) > 0 {
+		listeners = append(listeners, h.GuardListener())
+	}
+	opts := []bot.ConfigOpt{
 		bot.WithCacheConfigOpts(cache.WithCaches(cache.FlagsNone)),
-		bot.WithEventListeners(handlers.NewHandler()))
+		bot.WithRestClientConfigOpts(rest.WithHTTPClient(&http.Client{
+			Timeout:   20 * time.Second,
+			Transport: metrics.Transport(http.DefaultTransport),
+		})),
+		bot.WithEventListeners(listeners...),
+	}
+
+	// interactions are received over http instead of the gateway if an address is configured
+	muxes := make(map[string]*http.ServeMux)
+	interactionsAddress := cfg.Interactions.Address
+	if interactionsAddress != "" {
+		opts = append(opts, bot.WithHTTPServerConfigOpts(cfg.Interactions.PublicKey,
+			httpserver.WithAddress(interactionsAddress),
+			httpserver.WithURL(cfg.Interactions.Path),
+			httpserver.WithServeMux(serveMux(muxes, interactionsAddress))))
+	} else {
+		opts = append(opts, bot.WithGatewayConfigOpts(gateway.WithIntents(intents)))
+	}
+
+	client, err := disgo.New(cfg.Token, opts...)
 	if err != nil {
 		panic(err)
 	}
 
-	defer client.Close(context.TODO())
+	var guildIDs []snowflake.ID
+	if cfg.DevGuildID != 0 {
+		guildIDs = append(guildIDs, cfg.DevGuildID)
+	}
+	var disabled []string
+	if !cfg.Features.Schedules {
+		disabled = append(disabled, "/purge/schedule")
+	}
+	if !cfg.Features.Retentions {
+		disabled = append(disabled, "/retention")
+	}
+	commands := catalogs.LocalizeCommands(handlers.FilterCommands(handlers.Commands, disabled...))
+	if err := handler.SyncCommands(client, commands, guildIDs); err != nil {
+		panic(err)
+	}
+
+	var shutdown health.Shutdown
+	if address := cfg.HTTP.MetricsAddress; address != "" {
+		metrics.ObserveSessions(h.Sessions)
+		serveMux(muxes, address).Handle("/metrics", metrics.Handler())
+	}
+	if address := cfg.HTTP.HealthAddress; address != "" {
+		mux := serveMux(muxes, address)
+		mux.Handle("/healthz", health.Handler(map[string]health.Check{
+			"handlers": h.Live,
+		}))
+		ready := map[string]health.Check{
+			"shutdown": shutdown.Check,
+			"rest":     health.Rest(client, restCheckInterval),
+		}
+		if client.HasGateway() {
+			ready["gateway"] = health.Gateway(client)
+		}
+		mux.Handle("/readyz", health.Handler(ready))
+	}
+	var servers []*http.Server
+	for address, mux := range muxes {
+		if address == interactionsAddress {
+			// served by the client's http server
+			continue
+		}
+		server := &http.Server{Addr: address, Handler: mux}
+		servers = append(servers, server)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("error while serving http", slog.String("address", address), tint.Err(err))
+			}
+		}()
+	}
 
-	if err := client.OpenGateway(context.TODO()); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.RunJanitor(ctx, client)
+	go h.RunRecordPruner(ctx, cfg.Limits.RecordRetention)
+	if cfg.Features.Schedules {
+		go h.RunScheduler(ctx, client)
+	}
+	if cfg.Features.Retentions {
+		go h.RunRetention(ctx, client)
+	}
+
+	if client.HasHTTPServer() {
+		if err := client.OpenHTTPServer(); err != nil {
+			panic(err)
+		}
+	} else if err := client.OpenGateway(context.TODO()); err != nil {
 		panic(err)
 	}
 
@@ -41,4 +207,52 @@ func main() {
 	s := make(chan os.Signal, 1)
 	signal.Notify(s, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-s
+
+	// running purges may finish within the shutdown timeout, afterwards they are interrupted and store checkpoints
+	shutdownTimeout := cfg.Limits.ShutdownTimeout
+	slog.Info("shutting down...", slog.Duration("timeout", shutdownTimeout))
+	shutdown.Begin()
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := h.Drain(shutdownCtx); err != nil {
+		slog.Warn("interrupting purges still running on shutdown", tint.Err(err))
+		interruptCtx, cancelInterrupt := context.WithTimeout(context.Background(), interruptTimeout)
+		if err := h.Interrupt(interruptCtx); err != nil {
+			slog.Error("error while interrupting purges", tint.Err(err))
+		}
+		cancelInterrupt()
+	}
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancelClose()
+	client.Close(closeCtx)
+	for _, server := range servers {
+		if err := server.Shutdown(closeCtx); err != nil {
+			slog.Error("error while shutting down http", slog.String("address", server.Addr), tint.Err(err))
+		}
+	}
+}
+
+// serveMux returns the mux served on the address, endpoints sharing an address share a server.
+func serveMux(muxes map[string]*http.ServeMux, address string) *http.ServeMux {
+	mux, ok := muxes[address]
+	if !ok {
+		mux = http.NewServeMux()
+		muxes[address] = mux
+	}
+	return mux
+}
+
+// newLogHandler returns the log handler writing to stdout in the configured format and level.
+func newLogHandler(cfg config.LogConfig) slog.Handler {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.Level))
+	if cfg.Format == "json" {
+		return slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+			Level: level,
+		})
+	}
+	return tint.NewHandler(os.Stdout, &tint.Options{
+		Level: level,
+	})
 }
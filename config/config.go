@@ -0,0 +1,294 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Config is the configuration of the bot. It is loaded from a TOML file, every field can be overridden by the
+// environment variable in its env tag.
+type Config struct {
+	Token       string       `toml:"token" env:"ADVANCED_PURGE_TOKEN"`
+	DevGuildID  snowflake.ID `toml:"dev_guild_id" env:"ADVANCED_PURGE_GUILD_ID"`
+	StoragePath string       `toml:"storage_path" env:"ADVANCED_PURGE_STORAGE"`
+
+	Log          LogConfig          `toml:"log"`
+	HTTP         HTTPConfig         `toml:"http"`
+	Interactions InteractionsConfig `toml:"interactions"`
+	Limits       LimitsConfig       `toml:"limits"`
+	Features     FeaturesConfig     `toml:"features"`
+	Files        FilesConfig        `toml:"files"`
+	Marks        MarksConfig        `toml:"marks"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn and error.
+	Level string `toml:"level" env:"ADVANCED_PURGE_LOG_LEVEL"`
+	// Format is text for colored human readable lines or json.
+	Format string `toml:"format" env:"ADVANCED_PURGE_LOG_FORMAT"`
+}
+
+// HTTPConfig holds the addresses of the optional http endpoints, endpoints sharing an address share a server.
+type HTTPConfig struct {
+	MetricsAddress string `toml:"metrics_address" env:"ADVANCED_PURGE_METRICS_ADDRESS"`
+	HealthAddress  string `toml:"health_address" env:"ADVANCED_PURGE_HEALTH_ADDRESS"`
+}
+
+// InteractionsConfig switches to receiving interactions over http instead of the gateway if Address is set.
+type InteractionsConfig struct {
+	Address   string `toml:"address" env:"ADVANCED_PURGE_INTERACTIONS_ADDRESS"`
+	Path      string `toml:"path" env:"ADVANCED_PURGE_INTERACTIONS_PATH"`
+	PublicKey string `toml:"public_key" env:"ADVANCED_PURGE_PUBLIC_KEY"`
+}
+
+type LimitsConfig struct {
+	// IdleTimeout is the time after which idle purge setups expire.
+	IdleTimeout time.Duration `toml:"idle_timeout" env:"ADVANCED_PURGE_IDLE_TIMEOUT"`
+	// ShutdownTimeout is the time running purges may take to finish on shutdown before they are interrupted.
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout" env:"ADVANCED_PURGE_SHUTDOWN_TIMEOUT"`
+	// BulkLimit is the amount of messages purged at once unless a purge sets its own limit.
+	BulkLimit int `toml:"bulk_limit" env:"ADVANCED_PURGE_BULK_LIMIT"`
+	// MaxCount is the maximum amount of messages a purge may delete unless a role policy allows more, 0 means no limit.
+	MaxCount int `toml:"max_count" env:"ADVANCED_PURGE_MAX_COUNT"`
+	// IndexMessages is the maximum amount of messages indexed per channel.
+	IndexMessages int `toml:"index_messages" env:"ADVANCED_PURGE_INDEX_MESSAGES"`
+	// RecordRetention is the time after which purge records are deleted from the history.
+	RecordRetention time.Duration `toml:"record_retention" env:"ADVANCED_PURGE_RECORD_RETENTION"`
+}
+
+// FeaturesConfig toggles the optional features. Features which need further configuration stay disabled without it.
+type FeaturesConfig struct {
+	Index      bool `toml:"index" env:"ADVANCED_PURGE_FEATURE_INDEX"`
+	Guard      bool `toml:"guard" env:"ADVANCED_PURGE_FEATURE_GUARD"`
+	Approvals  bool `toml:"approvals" env:"ADVANCED_PURGE_FEATURE_APPROVALS"`
+	Schedules  bool `toml:"schedules" env:"ADVANCED_PURGE_FEATURE_SCHEDULES"`
+	Retentions bool `toml:"retentions" env:"ADVANCED_PURGE_FEATURE_RETENTIONS"`
+	// IndexChannels are the channels whose messages are indexed.
+	IndexChannels []snowflake.ID `toml:"index_channels" env:"ADVANCED_PURGE_INDEX_CHANNELS"`
+}
+
+// FilesConfig holds the paths of the JSON files configuring policies, guards and approvals per guild.
+type FilesConfig struct {
+	Policies  string `toml:"policies" env:"ADVANCED_PURGE_POLICIES"`
+	Guards    string `toml:"guards" env:"ADVANCED_PURGE_GUARDS"`
+	Approvals string `toml:"approvals" env:"ADVANCED_PURGE_APPROVALS"`
+}
+
+// MarksConfig holds the reaction emojis marking messages to purge or to keep.
+type MarksConfig struct {
+	Purge string `toml:"purge" env:"ADVANCED_PURGE_PURGE_EMOJI"`
+	Keep  string `toml:"keep" env:"ADVANCED_PURGE_KEEP_EMOJI"`
+}
+
+// Default returns the configuration used for everything not set in the file or the environment.
+func Default() Config {
+	return Config{
+		StoragePath: "advanced-purge.db",
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Interactions: InteractionsConfig{
+			Path: "/interactions/callback",
+		},
+		Limits: LimitsConfig{
+			IdleTimeout:     15 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			BulkLimit:       100,
+			IndexMessages:   10_000,
+			RecordRetention: 90 * 24 * time.Hour,
+		},
+		Features: FeaturesConfig{
+			Index:      true,
+			Guard:      true,
+			Approvals:  true,
+			Schedules:  true,
+			Retentions: true,
+		},
+		Marks: MarksConfig{
+			Purge: "🗑️",
+			Keep:  "🛡️",
+		},
+	}
+}
+
+// Load reads the config file at path on top of the defaults, applies the environment overrides and validates the
+// result. An empty path only uses the defaults and the environment.
+func Load(path string) (Config, error) {
+	config := Default()
+	if path != "" {
+		metadata, err := toml.DecodeFile(path, &config)
+		if err != nil {
+			return config, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return config, fmt.Errorf("unknown config keys: %s", strings.Join(keys, ", "))
+		}
+	}
+	err := override(reflect.ValueOf(&config).Elem())
+	return config, errors.Join(err, config.Validate())
+}
+
+// override sets the fields of the struct to the values of their environment variables if these are set.
+func override(v reflect.Value) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, override(value))
+			continue
+		}
+		name := field.Tag.Get("env")
+		text, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := set(value, text); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func set(value reflect.Value, text string) error {
+	switch value.Interface().(type) {
+	case string:
+		value.SetString(text)
+	case bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case snowflake.ID:
+		id, err := parseID(text)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(id))
+	case []snowflake.ID:
+		var ids []snowflake.ID
+		for _, part := range strings.Split(text, ",") {
+			id, err := parseID(part)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		value.Set(reflect.ValueOf(ids))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+func parseID(text string) (snowflake.ID, error) {
+	if text = strings.TrimSpace(text); text == "" {
+		return 0, nil
+	}
+	return snowflake.Parse(text)
+}
+
+// Validate reports all problems of the config at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Token == "" {
+		errs = append(errs, errors.New("token is required"))
+	}
+	if c.StoragePath == "" {
+		errs = append(errs, errors.New("storage_path is required"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level must be debug, info, warn or error, not %q", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format must be text or json, not %q", c.Log.Format))
+	}
+	for _, address := range []struct{ name, value string }{
+		{"http.metrics_address", c.HTTP.MetricsAddress},
+		{"http.health_address", c.HTTP.HealthAddress},
+		{"interactions.address", c.Interactions.Address},
+	} {
+		if address.value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(address.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s is invalid: %w", address.name, err))
+		}
+	}
+	if c.Interactions.Address != "" {
+		if key, err := hex.DecodeString(c.Interactions.PublicKey); err != nil || len(key) != 32 {
+			errs = append(errs, errors.New("interactions.public_key must be the application's hex encoded public key"))
+		}
+		if !strings.HasPrefix(c.Interactions.Path, "/") {
+			errs = append(errs, errors.New("interactions.path must start with /"))
+		}
+		if c.IndexEnabled() || c.GuardEnabled() {
+			errs = append(errs, errors.New("the message index and the anti-raid guard require the gateway, disable them to serve interactions over http"))
+		}
+	}
+	if c.Limits.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("limits.idle_timeout must be positive"))
+	}
+	if c.Limits.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("limits.shutdown_timeout must be positive"))
+	}
+	if c.Limits.BulkLimit < 2 || c.Limits.BulkLimit > 100 {
+		errs = append(errs, errors.New("limits.bulk_limit must be between 2 and 100"))
+	}
+	if c.Limits.MaxCount < 0 {
+		errs = append(errs, errors.New("limits.max_count must not be negative"))
+	}
+	if c.Limits.IndexMessages <= 0 {
+		errs = append(errs, errors.New("limits.index_messages must be positive"))
+	}
+	if c.Limits.RecordRetention <= 0 {
+		errs = append(errs, errors.New("limits.record_retention must be positive"))
+	}
+	if c.Marks.Purge == "" || c.Marks.Keep == "" {
+		errs = append(errs, errors.New("marks.purge and marks.keep are required"))
+	} else if c.Marks.Purge == c.Marks.Keep {
+		errs = append(errs, errors.New("marks.purge and marks.keep must differ"))
+	}
+	return errors.Join(errs...)
+}
+
+// IndexEnabled reports whether the message index is enabled and has channels to index.
+func (c Config) IndexEnabled() bool {
+	return c.Features.Index && len(c.Features.IndexChannels) > 0
+}
+
+// GuardEnabled reports whether the anti-raid guard is enabled and configured.
+func (c Config) GuardEnabled() bool {
+	return c.Features.Guard && c.Files.Guards != ""
+}
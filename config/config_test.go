@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("ADVANCED_PURGE_TOKEN", "token")
+	config, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.Token = "token"
+	if config.Limits != want.Limits || config.Log != want.Log || config.StoragePath != want.StoragePath {
+		t.Errorf("Load = %+v, want the defaults %+v", config, want)
+	}
+}
+
+func TestLoadFileAndOverrides(t *testing.T) {
+	path := writeConfig(t, `
+token = "file"
+storage_path = "file.db"
+
+[limits]
+idle_timeout = "5m"
+bulk_limit = 50
+
+[features]
+index_channels = [1, 2]
+`)
+	t.Setenv("ADVANCED_PURGE_TOKEN", "env")
+	t.Setenv("ADVANCED_PURGE_MAX_COUNT", "500")
+	t.Setenv("ADVANCED_PURGE_SHUTDOWN_TIMEOUT", "1m")
+	t.Setenv("ADVANCED_PURGE_FEATURE_GUARD", "false")
+	t.Setenv("ADVANCED_PURGE_INDEX_CHANNELS", "3, 4,5")
+	config, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Token != "env" || config.StoragePath != "file.db" {
+		t.Errorf("token = %q, storage path = %q, want env and file.db", config.Token, config.StoragePath)
+	}
+	if config.Limits.IdleTimeout != 5*time.Minute || config.Limits.ShutdownTimeout != time.Minute {
+		t.Errorf("timeouts = %s and %s, want 5m from the file and 1m from the environment", config.Limits.IdleTimeout, config.Limits.ShutdownTimeout)
+	}
+	if config.Limits.BulkLimit != 50 || config.Limits.MaxCount != 500 {
+		t.Errorf("bulk limit = %d, max count = %d, want 50 and 500", config.Limits.BulkLimit, config.Limits.MaxCount)
+	}
+	if config.Features.Guard || !config.Features.Index {
+		t.Errorf("features = %+v, want the guard disabled and the index enabled", config.Features)
+	}
+	if want := []snowflake.ID{3, 4, 5}; !equalIDs(config.Features.IndexChannels, want) {
+		t.Errorf("index channels = %v, want %v", config.Features.IndexChannels, want)
+	}
+}
+
+func equalIDs(a []snowflake.ID, b []snowflake.ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	path := writeConfig(t, `
+token = "token"
+
+[limits]
+idle_timeuot = "5m"
+`)
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "limits.idle_timeuot") {
+		t.Fatalf("Load = %v, want an error naming the unknown key", err)
+	}
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	t.Setenv("ADVANCED_PURGE_TOKEN", "")
+	t.Setenv("ADVANCED_PURGE_BULK_LIMIT", "1")
+	t.Setenv("ADVANCED_PURGE_MAX_COUNT", "-1")
+	t.Setenv("ADVANCED_PURGE_IDLE_TIMEOUT", "soon")
+	t.Setenv("ADVANCED_PURGE_LOG_LEVEL", "verbose")
+	_, err := Load("")
+	if err == nil {
+		t.Fatal("Load accepted an invalid config")
+	}
+	for _, want := range []string{
+		"invalid ADVANCED_PURGE_IDLE_TIMEOUT",
+		"token is required",
+		"log.level",
+		"limits.bulk_limit",
+		"limits.max_count",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load error %q does not report %q", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Default()
+	valid.Token = "token"
+	tests := []struct {
+		name   string
+		modify func(config *Config)
+		want   string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"no limit", func(config *Config) { config.Limits.MaxCount = 0 }, ""},
+		{"bulk limit too high", func(config *Config) { config.Limits.BulkLimit = 101 }, "limits.bulk_limit"},
+		{"no idle timeout", func(config *Config) { config.Limits.IdleTimeout = 0 }, "limits.idle_timeout"},
+		{"no record retention", func(config *Config) { config.Limits.RecordRetention = 0 }, "limits.record_retention"},
+		{"same marks", func(config *Config) { config.Marks.Keep = config.Marks.Purge }, "must differ"},
+		{"invalid address", func(config *Config) { config.HTTP.MetricsAddress = "8080" }, "http.metrics_address"},
+		{"http without public key", func(config *Config) {
+			config.Interactions.Address = ":8080"
+			config.Features.Guard = false
+		}, "interactions.public_key"},
+		{"http with index", func(config *Config) {
+			config.Interactions.Address = ":8080"
+			config.Interactions.PublicKey = strings.Repeat("ab", 32)
+			config.Features.IndexChannels = []snowflake.ID{1}
+		}, "require the gateway"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := valid
+			test.modify(&config)
+			err := config.Validate()
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("Validate = %v, want no error", err)
+			case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+				t.Errorf("Validate = %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
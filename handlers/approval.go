@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"advanced-purge/purge"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+const defaultApprovalTimeout = 15 * time.Minute
+
+// start runs the purge of count messages unless the guild requires a second moderator to approve it, in which case
+// an approval request is posted to the mod-log channel instead. The count is the confirmed estimate.
+func (h *Handler) start(event purgeEvent, p *purge.Purge, count int) error {
+	if err := event.DeferCreateMessage(false); err != nil {
+		return err
+	}
+	config, ok := h.approvals[*event.GuildID()]
+	if !ok || !config.Required(p.ChannelID, count) {
+		return h.run(event, p)
+	}
+	return h.requestApproval(event, p, config, count)
+}
+
+func (h *Handler) requestApproval(event purgeEvent, p *purge.Purge, config purge.ApprovalConfig, count int) error {
+	timeout := time.Duration(config.Timeout)
+	if timeout == 0 {
+		timeout = defaultApprovalTimeout
+	}
+	expiresAt := time.Now().Add(timeout)
+	h.controller.RequestApproval(p, count, expiresAt)
+
+	tr := h.translator(event)
+	reason := tr.T("approval.reason_count", count, config.Threshold)
+	if config.Protected(p.ChannelID) {
+		reason = tr.T("approval.reason_protected", discord.ChannelMention(p.ChannelID))
+	}
+	customID := fmt.Sprintf("/purge/approval/%d/%d", p.ChannelID, p.UserID)
+	request, err := event.Client().Rest().CreateMessage(config.ModLogChannelID, discord.NewMessageCreateBuilder().
+		SetContent(tr.T("approval.request",
+			discord.UserMention(p.UserID), count, discord.ChannelMention(p.ChannelID),
+			discord.MessageURL(*event.GuildID(), p.ChannelID, p.StartID),
+			discord.MessageURL(*event.GuildID(), p.ChannelID, p.EndID),
+			reason, discord.FormattedTimestampMention(expiresAt.Unix(), discord.TimestampStyleRelative))).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		AddActionRow(
+			discord.NewSuccessButton(tr.T("approval.approve_button"), customID+"/approve"),
+			discord.NewDangerButton(tr.T("approval.reject_button"), customID+"/reject")).
+		Build())
+	if err != nil {
+		h.controller.Reject(p)
+		p.Logger().Error("error while posting an approval request", tint.Err(err))
+		_, err = event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("approval.request_error", err)).
+			Build())
+		return err
+	}
+	h.controller.SetApprovalRequest(p, request.ChannelID, request.ID)
+	p.Logger().Info("requested the approval of a purge", slog.Int("count", count))
+
+	_, err = event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("approval.requested", reason, discord.ChannelMention(config.ModLogChannelID))).
+		AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+	return err
+}
+
+func (h *Handler) MiddlewareApprover() handler.Middleware {
+	return func(next handler.Handler) handler.Handler {
+		return func(event *handler.InteractionEvent) error {
+			tr := h.translator(event)
+			messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+			p := h.controller.Purge(snowflake.MustParse(event.Vars["channel-id"]), snowflake.MustParse(event.Vars["user-id"]))
+			if p == nil || !h.controller.Pending(p) {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("approval.not_pending")).
+					Build())
+			}
+			if p.UserID == event.User().ID {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("approval.own")).
+					Build())
+			}
+			member := event.Member()
+			config := h.approvals[*event.GuildID()]
+			if member == nil || (!member.Permissions.Has(discord.PermissionAdministrator) && !config.Eligible(member.RoleIDs)) {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("approval.not_eligible")).
+					Build())
+			}
+			return next(event)
+		}
+	}
+}
+
+func (h *Handler) HandleApprove(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(snowflake.MustParse(event.Vars["channel-id"]), snowflake.MustParse(event.Vars["user-id"]))
+	if !h.controller.Approve(p, event.User().ID) {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(tr.T("approval.not_pending")).
+			Build())
+	}
+	p.Logger().Info("approved a purge", slog.Any("approver.id", event.User().ID))
+	if err := event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("approval.approved", event.Message.Content, event.User().Mention())).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		ClearContainerComponents().
+		Build()); err != nil {
+		p.Logger().Error("error while marking an approval request as approved", tint.Err(err))
+	}
+	h.notify(event.Client(), p.UserID, h.guildTranslator(p.GuildID).T("approval.approved_notice", discord.ChannelMention(p.ChannelID), event.User().Mention()))
+	return h.run(&channelReporter{ComponentEvent: event, channelID: p.ChannelID}, p)
+}
+
+// channelReporter reports the progress of an approved purge in the purged channel, where its owner follows it,
+// instead of in the approver's interaction in the mod-log channel. The first response is posted as a new message
+// which later responses update, follow-ups are posted as new messages.
+type channelReporter struct {
+	*handler.ComponentEvent
+	channelID snowflake.ID
+	messageID snowflake.ID
+	mu        sync.Mutex
+}
+
+func (r *channelReporter) CreateMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) error {
+	_, err := r.CreateFollowupMessage(messageCreate, opts...)
+	return err
+}
+
+func (r *channelReporter) DeferCreateMessage(bool, ...rest.RequestOpt) error {
+	return nil
+}
+
+func (r *channelReporter) UpdateInteractionResponse(messageUpdate discord.MessageUpdate, opts ...rest.RequestOpt) (*discord.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.messageID != 0 {
+		return r.Client().Rest().UpdateMessage(r.channelID, r.messageID, messageUpdate, opts...)
+	}
+	messageCreate := discord.MessageCreate{
+		AllowedMentions: &discord.AllowedMentions{},
+	}
+	if messageUpdate.Content != nil {
+		messageCreate.Content = *messageUpdate.Content
+	}
+	if messageUpdate.Components != nil {
+		messageCreate.Components = *messageUpdate.Components
+	}
+	message, err := r.Client().Rest().CreateMessage(r.channelID, messageCreate, opts...)
+	if err == nil {
+		r.messageID = message.ID
+	}
+	return message, err
+}
+
+func (r *channelReporter) CreateFollowupMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) (*discord.Message, error) {
+	return r.Client().Rest().CreateMessage(r.channelID, messageCreate, opts...)
+}
+
+func (h *Handler) HandleReject(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(snowflake.MustParse(event.Vars["channel-id"]), snowflake.MustParse(event.Vars["user-id"]))
+	if !h.controller.Reject(p) {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(tr.T("approval.not_pending")).
+			Build())
+	}
+	p.Logger().Info("rejected a purge", slog.Any("approver.id", event.User().ID))
+	h.notify(event.Client(), p.UserID, h.guildTranslator(p.GuildID).T("approval.rejected_notice", discord.ChannelMention(p.ChannelID), event.User().Mention()))
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("approval.rejected", event.Message.Content, event.User().Mention())).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		ClearContainerComponents().
+		Build())
+}
+
+// expireApprovals withdraws the approval requests which have not been answered in time.
+func (h *Handler) expireApprovals(client bot.Client) {
+	for _, p := range h.controller.ExpireApprovals() {
+		logger := p.Logger()
+		tr := h.guildTranslator(p.GuildID)
+		approval := p.Approval
+		logger.Info("purge approval request expired")
+		h.notify(client, approval.UserID, tr.T("approval.expired_notice", discord.ChannelMention(approval.ChannelID)))
+		if approval.RequestID == 0 {
+			continue
+		}
+		if _, err := client.Rest().UpdateMessage(approval.RequestChannelID, approval.RequestID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("approval.expired")).
+			ClearContainerComponents().
+			Build()); err != nil {
+			logger.Error("error while marking an approval request as expired", tint.Err(err))
+		}
+	}
+}
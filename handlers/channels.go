@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+const (
+	channelsConcurrency    = 3
+	channelsUpdateInterval = 2 * time.Second
+)
+
+// parseDuration parses a duration like time.ParseDuration does, but also accepts whole days like 2d.
+func parseDuration(text string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(text)
+}
+
+// parseWindow parses a duration of up to 14 days, which is the maximum age of bulk deleted messages.
+func parseWindow(text string) (time.Duration, bool) {
+	window, err := parseDuration(text)
+	if err != nil || window <= 0 || window > 14*24*time.Hour {
+		return 0, false
+	}
+	return window, true
+}
+
+// channelsEvent is implemented by all interaction events which can start a purge in multiple channels.
+type channelsEvent interface {
+	Client() bot.Client
+	GuildID() *snowflake.ID
+	Locale() discord.Locale
+	User() discord.User
+	CreateMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) error
+	UpdateInteractionResponse(messageUpdate discord.MessageUpdate, opts ...rest.RequestOpt) (*discord.Message, error)
+	CreateFollowupMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) (*discord.Message, error)
+}
+
+// channelJobs returns a copy of the job for each of the channels.
+func channelJobs(job purge.Job, channelIDs []snowflake.ID) []purge.Job {
+	jobs := make([]purge.Job, len(channelIDs))
+	for i, channelID := range channelIDs {
+		jobs[i] = job
+		jobs[i].ChannelID = channelID
+	}
+	return jobs
+}
+
+// purgeChannels responds with the progress, runs the jobs in their channels in the background, keeps the interaction
+// response updated with the progress and reports the results per channel once all channels are done. The jobs remove
+// reactions instead of messages if reactions is set. The name of the purge is shown in the progress.
+func (h *Handler) purgeChannels(event channelsEvent, name string, record *purge.Record, reactions *purge.ReactionFilter, jobs []purge.Job) error {
+	tr := h.translator(event)
+	progress := newChannelsProgress(tr, name, jobs)
+	if err := event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetContent(progress.render()).
+		Build()); err != nil {
+		return err
+	}
+	logger := record.Logger().With(slog.String("purge", name))
+	ctx, done := h.controller.StartRun(context.Background())
+	go func() {
+		defer done()
+		content, groupID := h.runChannels(ctx, event.Client().Rest(), progress, *record, reactions, jobs, func(content string) {
+			if _, err := event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+				SetContent(content).
+				Build()); err != nil {
+				logger.Error("error while updating purge progress", tint.Err(err))
+			}
+		})
+		messageBuilder := discord.NewMessageCreateBuilder().SetContent(content)
+		if groupID != 0 {
+			messageBuilder.AddActionRow(resumeButton(tr, groupID))
+		}
+		if _, err := event.CreateFollowupMessage(messageBuilder.Build()); err != nil {
+			logger.Error("error while responding with a purge report", tint.Err(err))
+		}
+	}()
+	return nil
+}
+
+// runChannels runs the jobs in their channels with bounded parallelism, passes the rendered progress to update
+// from time to time and returns the report once all channels are done. Every channel gets its own copy of record.
+// Channels interrupted by a restart store checkpoints, their group ID is returned if there are any.
+func (h *Handler) runChannels(ctx context.Context, client rest.Rest, progress *channelsProgress, record purge.Record, reactions *purge.ReactionFilter, jobs []purge.Job, update func(content string)) (string, snowflake.ID) {
+	started := time.Now()
+	execute := h.index.Execute
+	if reactions != nil {
+		execute = reactions.Execute
+	}
+	var (
+		groupID     = snowflake.New(started)
+		checkpoints atomic.Bool
+		wg          sync.WaitGroup
+	)
+	sem := make(chan struct{}, channelsConcurrency)
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			channelRecord := record
+			total, err := h.recorded(execute, &channelRecord)(ctx, client, job, func(_ int, total int) error {
+				if progress.update(job.ChannelID, total) {
+					update(progress.render())
+				}
+				return nil
+			})
+			if errors.Is(context.Cause(ctx), purge.ErrInterrupted) {
+				err = purge.ErrInterrupted
+				if checkpoint, ok := purge.NewCheckpoint(groupID, channelRecord, job); ok {
+					if reactions != nil {
+						checkpoint.Reactions = *reactions
+					}
+					if h.putCheckpoint(channelRecord, checkpoint) {
+						checkpoints.Store(true)
+					}
+				}
+			}
+			progress.finish(job.ChannelID, total, err)
+			update(progress.render())
+		}()
+	}
+	wg.Wait()
+	if !checkpoints.Load() {
+		groupID = 0
+	}
+	return progress.report(time.Since(started)), groupID
+}
+
+type channelProgress struct {
+	total int
+	done  bool
+	err   error
+}
+
+// channelsProgress tracks the progress of a purge running in multiple channels.
+type channelsProgress struct {
+	tr         i18n.Translator
+	name       string
+	channelIDs []snowflake.ID
+	channels   map[snowflake.ID]*channelProgress
+	lastUpdate time.Time
+	mu         sync.Mutex
+}
+
+func newChannelsProgress(tr i18n.Translator, name string, jobs []purge.Job) *channelsProgress {
+	channelIDs := make([]snowflake.ID, len(jobs))
+	channels := make(map[snowflake.ID]*channelProgress, len(jobs))
+	for i, job := range jobs {
+		channelIDs[i] = job.ChannelID
+		channels[job.ChannelID] = &channelProgress{}
+	}
+	return &channelsProgress{
+		tr:         tr,
+		name:       name,
+		channelIDs: channelIDs,
+		channels:   channels,
+	}
+}
+
+// update sets the amount of purged messages in the channel and reports whether the progress view should be refreshed.
+func (p *channelsProgress) update(channelID snowflake.ID, total int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.channels[channelID].total = total
+	if time.Since(p.lastUpdate) < channelsUpdateInterval {
+		return false
+	}
+	p.lastUpdate = time.Now()
+	return true
+}
+
+func (p *channelsProgress) finish(channelID snowflake.ID, total int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	channel := p.channels[channelID]
+	channel.total = total
+	channel.done = true
+	channel.err = err
+}
+
+// render lists the channels which had messages purged or failed, other channels are only counted.
+func (p *channelsProgress) render() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		done  int
+		lines []string
+	)
+	for _, channelID := range p.channelIDs {
+		channel := p.channels[channelID]
+		if channel.done {
+			done++
+		}
+		status := "channels.purging"
+		switch {
+		case errors.Is(channel.err, purge.ErrInterrupted):
+			status = "channels.interrupted"
+		case channel.err != nil:
+			status = "channels.failed"
+		case channel.total == 0:
+			continue
+		case channel.done:
+			status = "channels.done"
+		}
+		lines = append(lines, p.tr.T("channels.progress_line", discord.ChannelMention(channelID), channel.total, p.tr.T(status)))
+	}
+	header := p.tr.T("channels.progress", p.name, done, len(p.channelIDs))
+	return joinLines(header, lines)
+}
+
+func (p *channelsProgress) report(duration time.Duration) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		total       int
+		interrupted bool
+		lines       []string
+	)
+	for _, channelID := range p.channelIDs {
+		channel := p.channels[channelID]
+		total += channel.total
+		switch {
+		case errors.Is(channel.err, purge.ErrInterrupted):
+			interrupted = true
+			lines = append(lines, p.tr.T("channels.report_interrupted", discord.ChannelMention(channelID), channel.total))
+		case channel.err != nil:
+			lines = append(lines, p.tr.T("channels.report_failed", discord.ChannelMention(channelID), channel.total, channel.err))
+		case channel.total > 0:
+			lines = append(lines, p.tr.T("channels.report_line", discord.ChannelMention(channelID), channel.total))
+		}
+	}
+	header := p.tr.T("channels.report", p.name, duration.Round(time.Second), total, len(p.channelIDs))
+	if interrupted {
+		header = p.tr.T("checkpoint.interrupted", total)
+	}
+	return joinLines(header, lines)
+}
+
+// joinLines joins the header and the lines while keeping the result within Discord's message length limit.
+func joinLines(header string, lines []string) string {
+	content := header
+	for i, line := range lines {
+		more := fmt.Sprintf("\n..and %d more", len(lines)-i)
+		if len(content)+len(line)+1+len(more) > 2000 {
+			return content + more
+		}
+		content += "\n" + line
+	}
+	return content
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+// resumeButton resumes the purges of the checkpoint group.
+func resumeButton(tr i18n.Translator, groupID snowflake.ID) discord.ButtonComponent {
+	return discord.NewPrimaryButton(tr.T("checkpoint.resume_button"), fmt.Sprintf("/purge/checkpoint/%d/resume", groupID))
+}
+
+// putCheckpoint stores the checkpoint and reports whether it could be stored.
+func (h *Handler) putCheckpoint(record purge.Record, checkpoint purge.Checkpoint) bool {
+	logger := record.Logger().With(slog.Any("checkpoint.id", checkpoint.ID))
+	if err := h.store.PutCheckpoint(checkpoint); err != nil {
+		logger.Error("error while saving a purge checkpoint", tint.Err(err))
+		return false
+	}
+	logger.Info("saved a purge checkpoint", slog.Int("deleted", checkpoint.Deleted))
+	return true
+}
+
+// interrupt stores the checkpoint of the purge's interrupted job and reports the interruption to its owner.
+func (h *Handler) interrupt(event purgeEvent, p *purge.Purge, record purge.Record, job purge.Job) {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder().
+		SetContent(tr.T("checkpoint.interrupted", record.Count))
+	if checkpoint, ok := purge.NewCheckpoint(record.ID, record, job); ok {
+		if p.Mode == purge.ModeReactions {
+			checkpoint.Reactions = p.Reactions
+		}
+		if h.putCheckpoint(record, checkpoint) {
+			messageBuilder.AddActionRow(resumeButton(tr, checkpoint.GroupID))
+		}
+	}
+	if _, err := event.CreateFollowupMessage(messageBuilder.Build()); err != nil {
+		record.Logger().Error("error while responding with a purge interruption", tint.Err(err))
+	}
+	h.controller.RemovePurge(p.ChannelID, p.UserID)
+}
+
+func (h *Handler) HandleResume(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	groupID := snowflake.MustParse(event.Vars["group-id"])
+	checkpoints, err := h.store.Checkpoints(groupID)
+	if err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("checkpoint.resumed")).
+			Build())
+	}
+	permissions, err := newPermissionResolver(event.Client().Rest(), event.ComponentInteraction, nil)
+	if err != nil {
+		return err
+	}
+	maxCounts := make([]int, len(checkpoints))
+	var denied []string
+	for i, checkpoint := range checkpoints {
+		maxCount, err := h.authorizeTarget(event.ComponentInteraction, permissions, checkpoint.ChannelID, checkpoint.Mode)
+		if err != nil {
+			denied = append(denied, fmt.Sprintf("%s: %s", discord.ChannelMention(checkpoint.ChannelID), denial(tr, err)))
+		}
+		maxCounts[i] = maxCount
+	}
+	if len(denied) > 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("checkpoint.denied", strings.Join(denied, "\n"))).
+			Build())
+	}
+
+	roles := memberRoles(event.Client().Rest(), *event.GuildID())
+	jobs := make([]purge.Job, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		if err := h.store.DeleteCheckpoint(checkpoint.ID); err != nil {
+			return err
+		}
+		jobs[i] = checkpoint.Job(roles)
+		// the resumed purge is limited by both the interrupted purge and the resuming member
+		if maxCounts[i] > 0 && (jobs[i].MaxCount == 0 || maxCounts[i] < jobs[i].MaxCount) {
+			jobs[i].MaxCount = maxCounts[i]
+		}
+	}
+	var reactions *purge.ReactionFilter
+	mode := checkpoints[0].Mode
+	if mode == purge.ModeReactions {
+		reactions = &checkpoints[0].Reactions
+	}
+	// the resumed purge keeps the correlation ID of the interrupted one
+	record := purge.NewRecord(*event.GuildID(), 0, event.User().ID, mode)
+	if correlationID := checkpoints[0].CorrelationID; correlationID != 0 {
+		record.CorrelationID = correlationID
+	}
+	// the purge can only be resumed once
+	if _, err := event.Client().Rest().UpdateMessage(event.Message.ChannelID, event.Message.ID, discord.NewMessageUpdateBuilder().
+		ClearContainerComponents().
+		Build()); err != nil {
+		record.Logger().Error("error while removing a resume button", tint.Err(err))
+	}
+	record.Logger().Info("resuming an interrupted purge", slog.Any("group.id", groupID), slog.Int("channels", len(jobs)))
+	return h.purgeChannels(event, tr.T("checkpoint.name"), record, reactions, jobs)
+}
@@ -1,26 +1,300 @@
 package handlers
 
 import (
+	"advanced-purge/health"
+	"advanced-purge/i18n"
 	"advanced-purge/purge"
+	"advanced-purge/storage"
+	"slices"
+	"time"
 
+	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/json"
 )
 
-func NewHandler() *Handler {
+var (
+	manageMessages = json.NewNullablePtr(discord.PermissionManageMessages)
+	manageGuild    = json.NewNullablePtr(discord.PermissionManageGuild)
+
+	Commands = []discord.ApplicationCommandCreate{
+		discord.SlashCommandCreate{
+			Name:                     "purge",
+			Description:              "Purge messages",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "setup",
+					Description: "Set up a simple or an advanced purge in this channel",
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "raid",
+					Description: "Purge recent messages across multiple channels",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionString{
+							Name:        "window",
+							Description: "How far back to purge, e.g. 30m, 2h or 2d",
+							Required:    true,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "channels",
+							Description: "Mentions of the channels to purge",
+						},
+						discord.ApplicationCommandOptionChannel{
+							Name:         "category",
+							Description:  "Category whose channels to purge",
+							ChannelTypes: []discord.ChannelType{discord.ChannelTypeGuildCategory},
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "authors",
+							Description: "Mentions of the users whose messages to purge",
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "forum",
+					Description: "Delete all threads of a forum created within a time window",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionChannel{
+							Name:         "forum",
+							Description:  "The forum whose threads to delete",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.ChannelTypeGuildForum, discord.ChannelTypeGuildMedia},
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "window",
+							Description: "How recently the threads were created, e.g. 30m, 2h or 2d",
+							Required:    true,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "schedule",
+					Description: "Schedule a purge to run once at a later time",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionString{
+							Name:        "at",
+							Description: "When to purge, e.g. 2h or 2025-01-02 15:04 (UTC)",
+							Required:    true,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "window",
+							Description: "Purge the messages posted within this window before the purge runs, e.g. 2h",
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "start",
+							Description: "Link or ID of the start message, used without a window",
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "end",
+							Description: "Link or ID of the end message, used without a window",
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "authors",
+							Description: "Mentions of the users whose messages to purge",
+						},
+						discord.ApplicationCommandOptionChannel{
+							Name:         "channel",
+							Description:  "The channel to purge, defaults to this one",
+							ChannelTypes: []discord.ChannelType{discord.ChannelTypeGuildText, discord.ChannelTypeGuildNews, discord.ChannelTypeGuildVoice, discord.ChannelTypeGuildStageVoice},
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "user",
+					Description: "Purge recent messages of a user across the whole server",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionUser{
+							Name:        "member",
+							Description: "The user whose messages to purge",
+							Required:    true,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "since",
+							Description: "How far back to purge, e.g. 30m, 6h or 2d",
+							Required:    true,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "history",
+					Description: "Show the purges run in this server",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionUser{
+							Name:        "user",
+							Description: "Only show purges run or approved by this user",
+						},
+						discord.ApplicationCommandOptionChannel{
+							Name:        "channel",
+							Description: "Only show purges in this channel",
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "since",
+							Description: "How far back to look, e.g. 6h or 7d",
+						},
+					},
+				},
+			},
+		},
+		discord.SlashCommandCreate{
+			Name:                     "retention",
+			Description:              "Manage recurring retention rules for channels",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "set",
+					Description: "Keep only recent messages in a channel",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionChannel{
+							Name:         "channel",
+							Description:  "The channel to apply the rule to",
+							Required:     true,
+							ChannelTypes: []discord.ChannelType{discord.ChannelTypeGuildText, discord.ChannelTypeGuildNews, discord.ChannelTypeGuildVoice, discord.ChannelTypeGuildStageVoice},
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "max-age",
+							Description: "Delete messages older than this, e.g. 24h or 30d",
+						},
+						discord.ApplicationCommandOptionInt{
+							Name:        "max-count",
+							Description: "Keep only this many of the newest messages",
+							MinValue:    json.Ptr(1),
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "every",
+							Description: "How often to enforce the rule, defaults to 1h",
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "list",
+					Description: "List the retention rules of this server",
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "remove",
+					Description: "Remove the retention rule of a channel",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionChannel{
+							Name:        "channel",
+							Description: "The channel whose rule to remove",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		discord.SlashCommandCreate{
+			Name:                     "language",
+			Description:              "Set the language of the bot in this server",
+			DefaultMemberPermissions: manageGuild,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionString{
+					Name:        "language",
+					Description: "The language, or automatically the Discord language of each member",
+					Required:    true,
+					Choices: []discord.ApplicationCommandOptionChoiceString{
+						{Name: "Automatic", Value: languageAuto},
+						{Name: "English", Value: string(discord.LocaleEnglishUS)},
+						{Name: "Deutsch", Value: string(discord.LocaleGerman)},
+						{Name: "Français", Value: string(discord.LocaleFrench)},
+					},
+				},
+			},
+		},
+		discord.MessageCommandCreate{
+			Name:                     "Set as start",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+		},
+		discord.MessageCommandCreate{
+			Name:                     "Set as end",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+		},
+		discord.MessageCommandCreate{
+			Name:                     "Exclude message",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+		},
+		discord.MessageCommandCreate{
+			Name:                     "Include message",
+			DefaultMemberPermissions: manageMessages,
+			Contexts:                 []discord.InteractionContextType{discord.InteractionContextTypeGuild},
+		},
+	}
+)
+
+func NewHandler(store *storage.Store, index *purge.Index, guard *purge.Guard, marks purge.Marks, approvals purge.ApprovalConfigs, policies purge.Policies, catalogs i18n.Catalogs, limits Limits) *Handler {
 	mux := handler.New()
 	handlers := &Handler{
 		controller: purge.NewController(),
+		store:      store,
+		index:      index,
+		guard:      guard,
+		marks:      marks,
+		approvals:  approvals,
+		policies:   policies,
+		catalogs:   catalogs,
+		locales:    newGuildLocales(),
+		limits:     limits,
+		inFlight:   health.NewTracker(),
 		Router:     mux,
 	}
 
-	mux.SlashCommand("/purge", handlers.HandlePurge)
+	mux.Use(handlers.MiddlewareInFlight(), handlers.MiddlewareMetrics(), handlers.MiddlewareAuthorize())
+	mux.Route("/purge", func(r handler.Router) {
+		r.SlashCommand("/setup", handlers.HandlePurge)
+		r.SlashCommand("/raid", handlers.HandleRaid)
+		r.SlashCommand("/user", handlers.HandleUser)
+		r.SlashCommand("/forum", handlers.HandleForum)
+		r.SlashCommand("/schedule", handlers.HandleSchedule)
+		r.SlashCommand("/history", handlers.HandleHistory)
+	})
+	mux.ButtonComponent("/purge/history/{user-id}/{channel-id}/{since}/{page}", handlers.HandleHistoryPage)
+	mux.SelectMenuComponent("/purge/history/detail", handlers.HandleHistoryDetail)
+	mux.ButtonComponent("/purge/schedule/{schedule-id}/cancel", handlers.HandleScheduleCancel)
+	mux.ButtonComponent("/purge/checkpoint/{group-id}/resume", handlers.HandleResume)
+	mux.ButtonComponent("/purge/forum/{forum-id}/{after}/{before}/confirm", handlers.HandleForumConfirm)
+	mux.ButtonComponent("/purge/forum/cancel", handlers.HandleForumCancel)
+	mux.Route("/purge/raid/{raid-id}", func(r handler.Router) {
+		r.ButtonComponent("/run", handlers.HandleRaidRun)
+		r.ButtonComponent("/dismiss", handlers.HandleRaidDismiss)
+	})
+	mux.Route("/retention", func(r handler.Router) {
+		r.SlashCommand("/set", handlers.HandleRetentionSet)
+		r.SlashCommand("/list", handlers.HandleRetentionList)
+		r.SlashCommand("/remove", handlers.HandleRetentionRemove)
+	})
+	mux.SlashCommand("/language", handlers.HandleLanguage)
+	mux.Route("/purge/approval/{channel-id}/{user-id}", func(r handler.Router) {
+		r.Use(handlers.MiddlewareApprover())
+
+		r.ButtonComponent("/approve", handlers.HandleApprove)
+		r.ButtonComponent("/reject", handlers.HandleReject)
+	})
+	mux.Route("/purge/owner/{user-id}", func(r handler.Router) {
+		r.Use(handlers.MiddlewareTakeOver())
+
+		r.ButtonComponent("/take-over", handlers.HandleTakeOver)
+		r.ButtonComponent("/force-cancel", handlers.HandleForceCancel)
+	})
 	mux.Group(func(r handler.Router) {
 		r.Use(handlers.MiddlewareButtonUser())
 
 		r.Route("/purge", func(r handler.Router) {
 			r.ButtonComponent("/simple", handlers.HandleSimple)
 			r.ButtonComponent("/advanced", handlers.HandleAdvanced)
+			r.ButtonComponent("/reactions", handlers.HandleReactions)
 			r.ButtonComponent("/cancel", handlers.HandleCancel)
+			r.ButtonComponent("/thread/confirm", handlers.HandleThreadConfirm)
+			r.ButtonComponent("/thread", handlers.HandleThread)
+
+			r.Modal("/amount", handlers.HandleAmount)
+			r.Modal("/limit", handlers.HandleLimit)
+			r.Modal("/reactions", handlers.HandleReactionsFilter)
 
 			r.Route("/start-change", func(r handler.Router) {
 				r.ButtonComponent("/keep", handlers.HandleStartKeep)
@@ -31,7 +305,20 @@ func NewHandler() *Handler {
 				r.ButtonComponent("/{new-id}", handlers.HandleEndChange)
 			})
 
+			r.Route("/exclude", func(r handler.Router) {
+				r.ButtonComponent("/range", handlers.HandleExcludeRange)
+				r.Modal("/range", handlers.HandleExcludeRangeSubmit)
+				r.ButtonComponent("/filter", handlers.HandleExcludeFilter)
+				r.SelectMenuComponent("/filter", handlers.HandleExcludeFilterSelect)
+			})
+			r.ButtonComponent("/exclusions", handlers.HandleReviewExclusions)
+			r.SelectMenuComponent("/exclusions", handlers.HandleUnexclude)
+			r.ButtonComponent("/collect", handlers.HandleCollect)
 			r.ButtonComponent("/run", handlers.HandleRun)
+			r.ButtonComponent("/confirm/{count}", handlers.HandleConfirm)
+			r.ButtonComponent("/confirm-name/{count}", handlers.HandleConfirmName)
+			r.Modal("/confirm-name/{count}", handlers.HandleConfirmNameSubmit)
+			r.ButtonComponent("/merge", handlers.HandleMerge)
 		})
 
 		r.MessageCommand("/Set as start", handlers.HandleStart)
@@ -41,11 +328,50 @@ func NewHandler() *Handler {
 		r.MessageCommand("/Include message", handlers.HandleInclude)
 
 	})
-	mux.Modal("/purge", handlers.HandleLimit)
 	return handlers
 }
 
+// FilterCommands returns the commands without the disabled top level commands and subcommands, which are given
+// as paths like /retention or /purge/schedule.
+func FilterCommands(commands []discord.ApplicationCommandCreate, disabled ...string) []discord.ApplicationCommandCreate {
+	var filtered []discord.ApplicationCommandCreate
+	for _, command := range commands {
+		path := "/" + command.CommandName()
+		if slices.Contains(disabled, path) {
+			continue
+		}
+		if slash, ok := command.(discord.SlashCommandCreate); ok {
+			slash.Options = slices.DeleteFunc(slices.Clone(slash.Options), func(option discord.ApplicationCommandOption) bool {
+				return slices.Contains(disabled, path+"/"+option.OptionName())
+			})
+			command = slash
+		}
+		filtered = append(filtered, command)
+	}
+	return filtered
+}
+
+// Limits are the default limits of purges and purge setups.
+type Limits struct {
+	// IdleTimeout is the time after which idle purge setups expire.
+	IdleTimeout time.Duration
+	// BulkLimit is the amount of messages purged at once unless a purge sets its own limit.
+	BulkLimit int
+	// MaxCount is the maximum amount of messages a purge may delete unless a role policy allows more, 0 means no limit.
+	MaxCount int
+}
+
 type Handler struct {
 	controller *purge.Controller
+	store      *storage.Store
+	index      *purge.Index
+	guard      *purge.Guard
+	marks      purge.Marks
+	approvals  purge.ApprovalConfigs
+	policies   purge.Policies
+	catalogs   i18n.Catalogs
+	locales    *guildLocales
+	limits     Limits
+	inFlight   *health.Tracker
 	handler.Router
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+// confirmNameThreshold is the estimated count above which the channel name has to be typed to confirm a purge.
+const confirmNameThreshold = 1000
+
+// confirm estimates the purge and shows a summary which has to be confirmed before the purge runs.
+func (h *Handler) confirm(event purgeEvent, p *purge.Purge) error {
+	if err := event.DeferCreateMessage(false); err != nil {
+		return err
+	}
+	// the estimate may scan the channel for minutes, so it runs in the background
+	go func() {
+		if err := h.showSummary(event, p); err != nil {
+			p.Logger().Error("error while responding with a purge summary", tint.Err(err))
+		}
+	}()
+	return nil
+}
+
+// showSummary estimates the purge and updates the deferred response with its summary.
+func (h *Handler) showSummary(event purgeEvent, p *purge.Purge) error {
+	tr := h.translator(event)
+	count, err := purge.Estimate(context.Background(), event.Client().Rest(), h.index, p.Job())
+	if err != nil {
+		p.Logger().Error("error while estimating a purge", tint.Err(err))
+		_, err = event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("estimate.error", err)).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+		return err
+	}
+
+	label := tr.T("confirm.delete_button", count)
+	customID := "/purge/confirm/" + strconv.Itoa(count)
+	if p.Mode == purge.ModeReactions {
+		label = tr.T("confirm.reactions_button", count)
+	}
+	if count > confirmNameThreshold {
+		customID = "/purge/confirm-name/" + strconv.Itoa(count)
+	}
+	_, err = event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(summary(tr, *event.GuildID(), p, count)).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		AddActionRow(
+			discord.NewDangerButton(label, customID),
+			discord.NewSecondaryButton(tr.T("button.review_exclusions"), "/purge/exclusions"),
+			discord.NewSecondaryButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+	return err
+}
+
+// summary describes everything the purge will do.
+func summary(tr i18n.Translator, guildID snowflake.ID, p *purge.Purge, count int) string {
+	direction := tr.T("confirm.backwards")
+	if p.Forwards {
+		direction = tr.T("confirm.forwards")
+	}
+	rangeLine := tr.T("confirm.range",
+		discord.MessageURL(guildID, p.ChannelID, p.StartID),
+		discord.MessageURL(guildID, p.ChannelID, p.EndID))
+	if p.Mode == purge.ModeSimple {
+		rangeLine = tr.T("confirm.range_amount", p.MaxCount)
+	}
+	lines := []string{tr.T("confirm.title"), rangeLine, direction}
+	if p.Mode == purge.ModeReactions {
+		emoji, user := tr.T("confirm.all_emojis"), tr.T("confirm.all_users")
+		if p.Reactions.Emoji != "" {
+			emoji = formatEmoji(p.Reactions.Emoji)
+		}
+		if p.Reactions.UserID != 0 {
+			user = discord.UserMention(p.Reactions.UserID)
+		}
+		lines = append(lines, tr.T("confirm.reactions", emoji, user))
+	} else if p.BulkLimit > 0 {
+		lines = append(lines, tr.T("confirm.bulk_limit", p.BulkLimit))
+	}
+	if p.MaxCount > 0 && p.Mode != purge.ModeSimple {
+		lines = append(lines, tr.T("confirm.max_count", p.MaxCount))
+	}
+	lines = append(lines, tr.T("confirm.exclusions", len(p.ExcludedMessages()), len(p.ExcludedRanges())))
+	filters := p.ExclusionFilters()
+	if len(filters) > 0 {
+		descriptions := make([]string, len(filters))
+		for i, filter := range filters {
+			descriptions[i] = filterDescription(tr, filter)
+		}
+		lines = append(lines, tr.T("confirm.filters", strings.Join(descriptions, "; ")))
+	}
+	lines = append(lines, tr.T("confirm.estimate", count))
+	return joinLines(lines[0], lines[1:])
+}
+
+func (h *Handler) HandleConfirm(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	count, _ := strconv.Atoi(event.Vars["count"])
+	return h.start(event, p, count)
+}
+
+func (h *Handler) HandleConfirmName(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	return event.Modal(discord.NewModalCreateBuilder().
+		SetTitle(tr.T("confirm.name_title")).
+		SetCustomID("/purge/confirm-name/" + event.Vars["count"]).
+		AddActionRow(
+			discord.NewShortTextInput("channel", tr.T("confirm.name_input")).
+				WithRequired(true).
+				WithMaxLength(100)).
+		Build())
+}
+
+func (h *Handler) HandleConfirmNameSubmit(event *handler.ModalEvent) error {
+	if !strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(event.Data.Text("channel")), "#"), event.Channel().Name()) {
+		tr := h.translator(event)
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("confirm.name_mismatch")).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+	}
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	count, _ := strconv.Atoi(event.Vars["count"])
+	return h.start(event, p, count)
+}
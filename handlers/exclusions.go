@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// exclusionActionRow offers the exclusions which apply to more than one message.
+func exclusionActionRow(tr i18n.Translator) discord.ActionRowComponent {
+	return discord.NewActionRow(
+		discord.NewSecondaryButton(tr.T("button.exclude_range"), "/purge/exclude/range"),
+		discord.NewSecondaryButton(tr.T("button.exclude_filter"), "/purge/exclude/filter"),
+		discord.NewSecondaryButton(tr.T("button.review_exclusions"), "/purge/exclusions"))
+}
+
+func (h *Handler) HandleExcludeRange(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	return event.Modal(discord.NewModalCreateBuilder().
+		SetTitle(tr.T("exclude_range.title")).
+		SetCustomID("/purge/exclude/range").
+		AddActionRow(
+			discord.NewShortTextInput("first", tr.T("exclude_range.first")).
+				WithRequired(true)).
+		AddActionRow(
+			discord.NewShortTextInput("last", tr.T("exclude_range.last")).
+				WithRequired(true)).
+		Build())
+}
+
+func (h *Handler) HandleExcludeRangeSubmit(event *handler.ModalEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	first := parseMessageID(event.Data.Text("first"))
+	last := parseMessageID(event.Data.Text("last"))
+	if first == 0 || last == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("exclude_range.invalid")).
+			Build())
+	}
+	if p.StartID == 0 || p.EndID == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("range.select_first")).
+			Build())
+	}
+	low, high := min(first, last), max(first, last)
+	purgeLow, purgeHigh := p.Range()
+	if high < purgeLow || low > purgeHigh {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("exclude_range.out_of_range")).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+	}
+	h.controller.ExcludeRange(p, low, high)
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("exclude_range.done",
+			discord.MessageURL(*event.GuildID(), p.ChannelID, low),
+			discord.MessageURL(*event.GuildID(), p.ChannelID, high))).
+		AddActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+}
+
+func (h *Handler) HandleExcludeFilter(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	return event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetContent(tr.T("exclude_filter.prompt")).
+		AddActionRow(discord.NewMentionableSelectMenu("/purge/exclude/filter", tr.T("exclude_filter.placeholder")).
+			WithMaxValues(25)).
+		Build())
+}
+
+func (h *Handler) HandleExcludeFilterSelect(data discord.SelectMenuInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	selected := data.(discord.MentionableSelectMenuInteractionData)
+	var (
+		userIDs  []snowflake.ID
+		roleIDs  []snowflake.ID
+		mentions []string
+	)
+	for _, id := range selected.Values {
+		if role, ok := selected.Resolved.Roles[id]; ok {
+			roleIDs = append(roleIDs, id)
+			mentions = append(mentions, role.Mention())
+			continue
+		}
+		userIDs = append(userIDs, id)
+		mentions = append(mentions, discord.UserMention(id))
+	}
+	description := "messages from " + strings.Join(mentions, ", ")
+	exclusion := purge.ExcludeAuthors(userIDs...)
+	if len(roleIDs) > 0 {
+		exclusion = exclusion.Or(purge.ExcludeRoles(memberRoles(event.Client().Rest(), *event.GuildID()), roleIDs...))
+	}
+	filter := purge.ExclusionFilter{
+		Description: description,
+		Exclude:     exclusion,
+		AuthorIDs:   userIDs,
+		RoleIDs:     roleIDs,
+	}
+	h.controller.ExcludeFilter(p, filter)
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("exclude_filter.done", filterDescription(tr, filter))).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		AddActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+}
+
+// filterDescription describes the messages the filter keeps in the translator's language. The filter's own
+// description is kept in English for the records.
+func filterDescription(tr i18n.Translator, filter purge.ExclusionFilter) string {
+	mentions := make([]string, 0, len(filter.AuthorIDs)+len(filter.RoleIDs))
+	for _, userID := range filter.AuthorIDs {
+		mentions = append(mentions, discord.UserMention(userID))
+	}
+	for _, roleID := range filter.RoleIDs {
+		mentions = append(mentions, discord.RoleMention(roleID))
+	}
+	if len(mentions) == 0 {
+		// merged filters exclude other setups' exclusions instead of authors or roles
+		return filter.Description
+	}
+	return tr.T("exclude_filter.description", strings.Join(mentions, ", "))
+}
+
+// memberRoles returns a RolesFunc which fetches members of the guild once. Users who are no longer members have no roles.
+func memberRoles(client rest.Rest, guildID snowflake.ID) purge.RolesFunc {
+	var (
+		roles = make(map[snowflake.ID][]snowflake.ID)
+		mu    sync.Mutex
+	)
+	return func(userID snowflake.ID) []snowflake.ID {
+		mu.Lock()
+		defer mu.Unlock()
+		if roleIDs, ok := roles[userID]; ok {
+			return roleIDs
+		}
+		var roleIDs []snowflake.ID
+		if member, err := client.GetMember(guildID, userID); err == nil {
+			roleIDs = member.RoleIDs
+		}
+		roles[userID] = roleIDs
+		return roleIDs
+	}
+}
+
+// maxReviewOptions is the maximum amount of options of a select menu.
+const maxReviewOptions = 25
+
+func (h *Handler) HandleReviewExclusions(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	if !p.HasExclusions() {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("exclusions.none")).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+	}
+	if err := event.DeferCreateMessage(false); err != nil {
+		return err
+	}
+	guildID := *event.GuildID()
+	var (
+		lines   []string
+		options []discord.StringSelectMenuOption
+	)
+	for _, messageID := range p.ExcludedMessages() {
+		jumpURL := discord.MessageURL(guildID, p.ChannelID, messageID)
+		if len(options) == maxReviewOptions {
+			lines = append(lines, tr.T("exclusions.message", jumpURL))
+			continue
+		}
+		author, text := tr.T("exclusions.unknown_author"), tr.T("exclusions.unavailable")
+		if message, err := event.Client().Rest().GetMessage(p.ChannelID, messageID); err == nil {
+			author, text = message.Author.EffectiveName(), preview(tr, *message)
+		}
+		lines = append(lines, tr.T("exclusions.message_preview", jumpURL, author, text))
+		options = append(options, discord.NewStringSelectMenuOption(truncate(author+": "+text, 100), "message:"+messageID.String()).
+			WithDescription(messageID.Time().UTC().Format(time.DateTime)))
+	}
+	for _, r := range p.ExcludedRanges() {
+		lines = append(lines, tr.T("exclusions.range",
+			discord.MessageURL(guildID, p.ChannelID, r.Low),
+			discord.MessageURL(guildID, p.ChannelID, r.High)))
+		if len(options) < maxReviewOptions {
+			options = append(options, discord.NewStringSelectMenuOption(tr.T("exclusions.range_option"), fmt.Sprintf("range:%d:%d", r.Low, r.High)).
+				WithDescription(tr.T("exclusions.range_description", r.Low.Time().UTC().Format(time.DateTime), r.High.Time().UTC().Format(time.DateTime))))
+		}
+	}
+	for _, filter := range p.ExclusionFilters() {
+		description := filterDescription(tr, filter)
+		lines = append(lines, "- "+description)
+		if len(options) < maxReviewOptions {
+			options = append(options, discord.NewStringSelectMenuOption(tr.T("exclusions.filter_option"), "filter:"+strconv.Itoa(filter.ID)).
+				WithDescription(truncate(description, 100)))
+		}
+	}
+	_, err := event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(joinLines(tr.T("exclusions.title"), lines)).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		AddActionRow(discord.NewStringSelectMenu("/purge/exclusions", tr.T("exclusions.placeholder"), options...).
+			WithMaxValues(len(options))).
+		AddActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+	return err
+}
+
+func (h *Handler) HandleUnexclude(data discord.SelectMenuInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	var (
+		messageIDs []snowflake.ID
+		ranges     []purge.ExcludedRange
+		filterIDs  []int
+	)
+	for _, value := range data.(discord.StringSelectMenuInteractionData).Values {
+		kind, id, _ := strings.Cut(value, ":")
+		switch kind {
+		case "message":
+			messageIDs = append(messageIDs, snowflake.MustParse(id))
+		case "range":
+			low, high, _ := strings.Cut(id, ":")
+			ranges = append(ranges, purge.ExcludedRange{Low: snowflake.MustParse(low), High: snowflake.MustParse(high)})
+		case "filter":
+			filterID, _ := strconv.Atoi(id)
+			filterIDs = append(filterIDs, filterID)
+		}
+	}
+	h.controller.RemoveExclusions(p, messageIDs, ranges, filterIDs)
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("exclusions.removed", len(messageIDs)+len(ranges)+len(filterIDs))).
+		AddActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewSecondaryButton(tr.T("button.review_exclusions"), "/purge/exclusions"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+}
+
+// preview returns a short single line preview of the message's content.
+func preview(tr i18n.Translator, message discord.Message) string {
+	content := strings.Join(strings.Fields(message.Content), " ")
+	switch {
+	case content != "":
+		return truncate(content, 60)
+	case len(message.Attachments) > 0:
+		return tr.T("exclusions.attachment")
+	case len(message.Embeds) > 0:
+		return tr.T("exclusions.embed")
+	default:
+		return tr.T("exclusions.no_content")
+	}
+}
+
+// truncate shortens the text to at most n characters.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+// GuardListener feeds the guild messages to the anti-raid guard and handles the detected raids.
+func (h *Handler) GuardListener() bot.EventListener {
+	return bot.NewListenerFunc(func(event *events.GuildMessageCreate) {
+		message := event.Message
+		if message.Author.Bot || message.WebhookID != nil {
+			return
+		}
+		guardMessage := purge.GuardMessage{
+			ID:        message.ID,
+			ChannelID: message.ChannelID,
+			AuthorID:  message.Author.ID,
+			Content:   message.Content,
+			Mentions:  len(message.Mentions) + len(message.MentionRoles),
+		}
+		if message.MentionEveryone {
+			guardMessage.Mentions++
+		}
+		if message.Member != nil {
+			guardMessage.JoinedAt = message.Member.JoinedAt
+		}
+		if raid := h.guard.Observe(event.GuildID, guardMessage); raid != nil {
+			go h.handleRaid(event.Client(), *raid)
+		}
+	})
+}
+
+// handleRaid purges the raid or posts a prompt to purge it in the mod-log channel. It makes REST calls, so it must
+// not run in the gateway's event listener.
+func (h *Handler) handleRaid(client bot.Client, raid purge.Raid) {
+	config, _ := h.guard.Config(raid.GuildID)
+	slog.Info("detected a raid", slog.Any("guild.id", raid.GuildID), slog.Any("raid.id", raid.ID), slog.Int("channels", len(raid.ChannelIDs)), slog.Int("authors", len(raid.AuthorIDs)))
+	if config.AutoRun {
+		h.runRaid(client, config, raid)
+		return
+	}
+	h.guard.AddRaid(raid)
+	tr := h.guildTranslator(raid.GuildID)
+	_, err := client.Rest().CreateMessage(config.ModLogChannelID, discord.NewMessageCreateBuilder().
+		SetContent(tr.T("guard.prompt", tr.T(raid.Reason, raid.ReasonArgs...), describeRaid(tr, raid))).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		AddActionRow(
+			discord.NewDangerButton(tr.T("guard.run_button"), "/purge/raid/"+raid.ID.String()+"/run"),
+			discord.NewSecondaryButton(tr.T("guard.dismiss_button"), "/purge/raid/"+raid.ID.String()+"/dismiss"),
+		).
+		Build())
+	if err != nil {
+		slog.Error("error while posting a raid prompt", slog.Any("guild.id", raid.GuildID), tint.Err(err))
+	}
+}
+
+// runRaid purges the raid without a moderator and reports the progress in the mod-log channel. Each channel is limited
+// to the default max count.
+func (h *Handler) runRaid(client bot.Client, config purge.GuardConfig, raid purge.Raid) {
+	job := raid.Job(time.Now())
+	job.MaxCount = h.limits.MaxCount
+	jobs := channelJobs(job, raid.ChannelIDs)
+	tr := h.guildTranslator(raid.GuildID)
+	progress := newChannelsProgress(tr, tr.T("raid.name"), jobs)
+	message, err := client.Rest().CreateMessage(config.ModLogChannelID, discord.NewMessageCreateBuilder().
+		SetContent(tr.T("guard.auto", tr.T(raid.Reason, raid.ReasonArgs...), describeRaid(tr, raid))).
+		SetAllowedMentions(&discord.AllowedMentions{}).
+		Build())
+	if err != nil {
+		slog.Error("error while posting a raid notice", slog.Any("guild.id", raid.GuildID), tint.Err(err))
+	}
+	record := purge.NewRecord(raid.GuildID, 0, client.ID(), purge.ModeRaid)
+	logger := record.Logger().With(slog.Any("raid.id", raid.ID))
+	logger.Info("starting an automatic raid purge", slog.Int("channels", len(jobs)))
+	ctx, done := h.controller.StartRun(context.Background())
+	defer done()
+	content, groupID := h.runChannels(ctx, client.Rest(), progress, *record, nil, jobs, func(content string) {
+		if message == nil {
+			return
+		}
+		if _, err := client.Rest().UpdateMessage(message.ChannelID, message.ID, discord.NewMessageUpdateBuilder().
+			SetContent(content).
+			Build()); err != nil {
+			logger.Error("error while updating purge progress", tint.Err(err))
+		}
+	})
+	messageBuilder := discord.NewMessageCreateBuilder().SetContent(content)
+	if groupID != 0 {
+		messageBuilder.AddActionRow(resumeButton(tr, groupID))
+	}
+	if _, err := client.Rest().CreateMessage(config.ModLogChannelID, messageBuilder.Build()); err != nil {
+		logger.Error("error while posting a raid purge report", tint.Err(err))
+	}
+}
+
+func (h *Handler) HandleRaidRun(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	raidID := snowflake.MustParse(event.Vars["raid-id"])
+	raid, ok := h.guard.TakeRaid(raidID)
+	if !ok {
+		return event.CreateMessage(messageBuilder.
+			SetContent(h.translator(event).T("guard.gone")).
+			Build())
+	}
+	maxCounts, err := h.authorizeRaid(event, raid)
+	if err != nil {
+		return err
+	}
+	if maxCounts == nil {
+		return nil
+	}
+	record := purge.NewRecord(raid.GuildID, 0, event.User().ID, purge.ModeRaid)
+	record.Logger().Info("starting a detected raid purge", slog.Any("raid.id", raid.ID))
+	return h.purgeChannels(event, h.translator(event).T("raid.name"), record, nil, limitJobs(channelJobs(raid.Job(time.Now()), raid.ChannelIDs), maxCounts))
+}
+
+func (h *Handler) HandleRaidDismiss(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	raidID := snowflake.MustParse(event.Vars["raid-id"])
+	tr := h.translator(event)
+	raid, ok := h.guard.TakeRaid(raidID)
+	if !ok {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(tr.T("guard.gone")).
+			Build())
+	}
+	if maxCounts, err := h.authorizeRaid(event, raid); err != nil || maxCounts == nil {
+		return err
+	}
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("guard.dismissed", event.Message.Content, event.User().Mention())).
+		ClearContainerComponents().
+		Build())
+}
+
+// authorizeRaid checks whether the member may purge the raid in all of its channels and returns the max count per
+// channel. Otherwise it puts the raid back, responds with the denied channels and returns nil.
+func (h *Handler) authorizeRaid(event *handler.ComponentEvent, raid purge.Raid) ([]int, error) {
+	permissions, err := newPermissionResolver(event.Client().Rest(), event.ComponentInteraction, nil)
+	if err != nil {
+		h.guard.AddRaid(raid)
+		return nil, err
+	}
+	tr := h.translator(event)
+	maxCounts, denied := h.authorizeTargets(tr, event.ComponentInteraction, permissions, raid.ChannelIDs, purge.ModeRaid)
+	if len(denied) == 0 {
+		return maxCounts, nil
+	}
+	h.guard.AddRaid(raid)
+	return nil, event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetEphemeral(true).
+		SetContent(tr.T("guard.denied", strings.Join(denied, "\n"))).
+		Build())
+}
+
+// describeRaid lists the raid's channels and authors.
+func describeRaid(tr i18n.Translator, raid purge.Raid) string {
+	channels := make([]string, len(raid.ChannelIDs))
+	for i, channelID := range raid.ChannelIDs {
+		channels[i] = discord.ChannelMention(channelID)
+	}
+	return tr.T("guard.description",
+		strings.Join(channels, ", "),
+		mentionUsers(raid.AuthorIDs),
+		discord.FormattedTimestampMention(raid.FirstID.Time().Unix(), discord.TimestampStyleLongTime))
+}
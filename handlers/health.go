@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// handlerTimeout is the time after which a handler in flight is considered deadlocked, handlers have to respond
+// within seconds and run long work in the background.
+const handlerTimeout = 2 * time.Minute
+
+// Live fails if a handler is deadlocked or the purge setups can not be accessed.
+func (h *Handler) Live(ctx context.Context) error {
+	if err := h.inFlight.Stalled(handlerTimeout)(ctx); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		h.controller.Len()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("purge setups are locked")
+	}
+}
+
+// Drain waits until no purge is running anymore or ctx is done.
+func (h *Handler) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for h.controller.Runs() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Interrupt cancels all running purges and waits until they have reported their progress and stored their
+// checkpoints or ctx is done.
+func (h *Handler) Interrupt(ctx context.Context) error {
+	h.controller.Interrupt()
+	return h.Drain(ctx)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"advanced-purge/metrics"
+	"advanced-purge/purge"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+const historyPageSize = 10
+
+// executeFunc is implemented by purge.Execute, the index and the other executors.
+type executeFunc func(ctx context.Context, client rest.Rest, job purge.Job, progress purge.ProgressFunc) (int, error)
+
+// recorded wraps execute to persist the record of the run in the audit trail and count it in the metrics.
+func (h *Handler) recorded(execute executeFunc, record *purge.Record) executeFunc {
+	return func(ctx context.Context, client rest.Rest, job purge.Job, progress purge.ProgressFunc) (int, error) {
+		mode := string(record.Mode)
+		metrics.PurgesStarted.WithLabelValues(mode).Inc()
+		if job.BulkLimit == 0 {
+			job.BulkLimit = h.limits.BulkLimit
+		}
+		job = record.Track(job)
+		logger := record.Logger().With(slog.Any("record.id", record.ID))
+		logger.Debug("running a purge")
+		total, err := execute(ctx, client, job, progress)
+		if err != nil {
+			metrics.PurgesFailed.WithLabelValues(mode).Inc()
+		} else {
+			metrics.PurgesCompleted.WithLabelValues(mode).Inc()
+		}
+		record.Finish(total, err)
+		switch {
+		case errors.Is(context.Cause(ctx), purge.ErrInterrupted):
+			logger.Warn("interrupted a purge", slog.Int("total", total), slog.Duration("duration", record.Duration))
+		case err != nil:
+			logger.Error("error while running a purge", slog.Int("total", total), slog.Duration("duration", record.Duration), tint.Err(err))
+		default:
+			logger.Info("finished a purge", slog.Int("total", total), slog.Duration("duration", record.Duration))
+		}
+		// retention rules run every interval, only the runs which purged anything or failed are kept
+		if record.Mode == purge.ModeRetention && total == 0 && err == nil {
+			return total, err
+		}
+		if err := h.store.PutRecord(*record); err != nil {
+			logger.Error("error while saving a purge record", tint.Err(err))
+		}
+		return total, err
+	}
+}
+
+func (h *Handler) HandleHistory(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	filter := purge.RecordFilter{
+		GuildID: *event.GuildID(),
+	}
+	if user, ok := data.OptUser("user"); ok {
+		filter.UserID = user.ID
+	}
+	if channel, ok := data.OptChannel("channel"); ok {
+		filter.ChannelID = channel.ID
+	}
+	if text, ok := data.OptString("since"); ok {
+		since, err := parseDuration(text)
+		if err != nil || since <= 0 {
+			return event.CreateMessage(discord.NewMessageCreateBuilder().
+				SetEphemeral(true).
+				SetContent("Provide a duration like `12h` or `30d`.").
+				Build())
+		}
+		filter.Since = time.Now().Add(-since)
+	}
+	messageCreate, err := h.historyPage(filter, 0)
+	if err != nil {
+		return err
+	}
+	return event.CreateMessage(discord.MessageCreate{
+		Content:         messageCreate.Content,
+		Components:      messageCreate.Components,
+		AllowedMentions: &discord.AllowedMentions{},
+		Flags:           discord.MessageFlagEphemeral,
+	})
+}
+
+func (h *Handler) HandleHistoryPage(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	filter := purge.RecordFilter{
+		GuildID:   *event.GuildID(),
+		UserID:    snowflake.MustParse(event.Vars["user-id"]),
+		ChannelID: snowflake.MustParse(event.Vars["channel-id"]),
+	}
+	if since, _ := strconv.ParseInt(event.Vars["since"], 10, 64); since > 0 {
+		filter.Since = time.Unix(since, 0)
+	}
+	page, _ := strconv.Atoi(event.Vars["page"])
+	messageCreate, err := h.historyPage(filter, page)
+	if err != nil {
+		return err
+	}
+	return event.UpdateMessage(discord.MessageUpdate{
+		Content:    &messageCreate.Content,
+		Components: &messageCreate.Components,
+	})
+}
+
+// historyPage renders a page of the records matching the filter.
+func (h *Handler) historyPage(filter purge.RecordFilter, page int) (discord.MessageCreate, error) {
+	records, err := h.store.Records(filter)
+	if err != nil {
+		return discord.MessageCreate{}, err
+	}
+	if len(records) == 0 {
+		return discord.MessageCreate{Content: "No purges match your query."}, nil
+	}
+	pages := (len(records) + historyPageSize - 1) / historyPageSize
+	page = max(0, min(page, pages-1))
+	records = records[page*historyPageSize : min((page+1)*historyPageSize, len(records))]
+
+	lines := make([]string, len(records))
+	options := make([]discord.StringSelectMenuOption, len(records))
+	for i, record := range records {
+		status := "done"
+		if record.Error != "" {
+			status = "failed"
+		}
+		lines[i] = fmt.Sprintf("- %s **%s** purge by %s in %s: **%d** %s (%s)",
+			discord.FormattedTimestampMention(record.StartedAt.Unix(), discord.TimestampStyleShortDateTime),
+			record.Mode, discord.UserMention(record.UserID), discord.ChannelMention(record.ChannelID), record.Count, recordUnit(record), status)
+		options[i] = discord.NewStringSelectMenuOption(
+			fmt.Sprintf("%s %s purge: %d %s", record.StartedAt.UTC().Format(time.DateTime), record.Mode, record.Count, recordUnit(record)),
+			record.ID.String())
+	}
+
+	var since int64
+	if !filter.Since.IsZero() {
+		since = filter.Since.Unix()
+	}
+	customID := fmt.Sprintf("/purge/history/%d/%d/%d/", filter.UserID, filter.ChannelID, since)
+	header := fmt.Sprintf("Purge history (page **%d/%d**):", page+1, pages)
+	return discord.MessageCreate{
+		Content: joinLines(header, lines),
+		Components: []discord.ContainerComponent{
+			discord.NewActionRow(discord.NewStringSelectMenu("/purge/history/detail", "Show details of..", options...)),
+			discord.NewActionRow(
+				discord.NewSecondaryButton("Previous", customID+strconv.Itoa(page-1)).WithDisabled(page == 0),
+				discord.NewSecondaryButton("Next", customID+strconv.Itoa(page+1)).WithDisabled(page == pages-1)),
+		},
+	}, nil
+}
+
+func (h *Handler) HandleHistoryDetail(data discord.SelectMenuInteractionData, event *handler.ComponentEvent) error {
+	messageBuilder := discord.NewMessageCreateBuilder().
+		SetEphemeral(true).
+		SetAllowedMentions(&discord.AllowedMentions{})
+	values := data.(discord.StringSelectMenuInteractionData).Values
+	record, err := h.store.Record(*event.GuildID(), snowflake.MustParse(values[0]))
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent("This purge record does not exist.").
+			Build())
+	}
+	lines := []string{
+		fmt.Sprintf("- Run by: %s", discord.UserMention(record.UserID)),
+		fmt.Sprintf("- Channel: %s", discord.ChannelMention(record.ChannelID)),
+		fmt.Sprintf("- Started: %s", discord.FormattedTimestampMention(record.StartedAt.Unix(), discord.TimestampStyleLongDateTime)),
+		fmt.Sprintf("- Duration: %s", record.Duration.Round(time.Millisecond)),
+		fmt.Sprintf("- Count: **%d**", record.Count),
+		fmt.Sprintf("- Correlation ID: `%d`", record.CorrelationID),
+	}
+	if record.ApproverID != 0 {
+		lines = append(lines, fmt.Sprintf("- Approved by: %s", discord.UserMention(record.ApproverID)))
+	}
+	if record.StartID != 0 && record.EndID != 0 {
+		lines = append(lines, fmt.Sprintf("- Range: [start](%s) to [end](%s)",
+			discord.MessageURL(record.GuildID, record.ChannelID, record.StartID),
+			discord.MessageURL(record.GuildID, record.ChannelID, record.EndID)))
+	}
+	if len(record.Filters) > 0 {
+		lines = append(lines, "- Exclusions: "+strings.Join(record.Filters, "; "))
+	}
+	if record.Error != "" {
+		lines = append(lines, fmt.Sprintf("- Error: **%s**", record.Error))
+	}
+	messageBuilder.SetContent(joinLines(fmt.Sprintf("**%s** purge `%d`:", record.Mode, record.ID), lines))
+	if len(record.MessageIDs) > 0 {
+		var ids bytes.Buffer
+		for _, messageID := range record.MessageIDs {
+			ids.WriteString(messageID.String() + "\n")
+		}
+		messageBuilder.AddFile(fmt.Sprintf("purge-%d.txt", record.ID), "IDs of the deleted "+recordUnit(*record), &ids)
+	}
+	return event.CreateMessage(messageBuilder.Build())
+}
+
+// recordUnit returns what the record counts, the deleted threads of thread and forum purges and messages otherwise.
+func recordUnit(record purge.Record) string {
+	if record.Mode == purge.ModeThread || record.Mode == purge.ModeForum {
+		return "threads"
+	}
+	return "messages"
+}
+
+// RunRecordPruner deletes the records older than maxAge from the history every hour until ctx is done.
+func (h *Handler) RunRecordPruner(ctx context.Context, maxAge time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		pruned, err := h.store.PruneRecords(time.Now().Add(-maxAge))
+		if err != nil {
+			slog.Error("error while pruning purge records", tint.Err(err))
+		} else if pruned > 0 {
+			slog.Info("pruned purge records", slog.Int("pruned", pruned))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// describeExclusions summarizes the exclusions of the purge for its record.
+func describeExclusions(p *purge.Purge) []string {
+	var exclusions []string
+	if messageIDs := p.ExcludedMessages(); len(messageIDs) > 0 {
+		exclusions = append(exclusions, fmt.Sprintf("%d single messages", len(messageIDs)))
+	}
+	for _, r := range p.ExcludedRanges() {
+		exclusions = append(exclusions, fmt.Sprintf("messages %d to %d", r.Low, r.High))
+	}
+	for _, filter := range p.ExclusionFilters() {
+		exclusions = append(exclusions, filter.Description)
+	}
+	return exclusions
+}
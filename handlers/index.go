@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// IndexListener keeps the message index up to date with the gateway's message events.
+func (h *Handler) IndexListener() bot.EventListener {
+	return &events.ListenerAdapter{
+		OnReady: func(event *events.Ready) {
+			// events may have been missed before this session
+			h.index.Reset(snowflake.New(time.Now()))
+			slog.Info("started indexing messages")
+		},
+		OnGuildMessageCreate: func(event *events.GuildMessageCreate) {
+			h.index.Put(event.Message)
+		},
+		OnGuildMessageUpdate: func(event *events.GuildMessageUpdate) {
+			h.index.Put(event.Message)
+		},
+		OnGuildMessageDelete: func(event *events.GuildMessageDelete) {
+			h.index.Remove(event.ChannelID, event.MessageID)
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/lmittmann/tint"
+)
+
+// RunJanitor expires purge setups which have been idle for longer than the idle timeout until ctx is done.
+func (h *Handler) RunJanitor(ctx context.Context, client bot.Client) {
+	ticker := time.NewTicker(min(h.limits.IdleTimeout, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.expireApprovals(client)
+			for _, purge := range h.controller.Expire(h.limits.IdleTimeout) {
+				purge.Logger().Info("purge setup expired")
+				if purge.PromptID == 0 {
+					continue
+				}
+				_, err := client.Rest().UpdateMessage(purge.ChannelID, purge.PromptID, discord.NewMessageUpdateBuilder().
+					SetContent(h.guildTranslator(purge.GuildID).T("setup.expired", h.limits.IdleTimeout)).
+					ClearContainerComponents().
+					Build())
+				if err != nil {
+					purge.Logger().Error("error while marking a purge setup as expired", tint.Err(err))
+				}
+			}
+		}
+	}
+}
+
+// Sessions returns the amount of active purge setups.
+func (h *Handler) Sessions() int {
+	return h.controller.Len()
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"log/slog"
+	"sync"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+// languageAuto clears the guild's locale override.
+const languageAuto = "auto"
+
+// localeEvent is implemented by all interaction events.
+type localeEvent interface {
+	GuildID() *snowflake.ID
+	Locale() discord.Locale
+}
+
+// guildLocales caches the locale overrides of the guilds as the translators of every interaction need them. Guilds
+// without an override are cached with an empty locale.
+type guildLocales struct {
+	mu      sync.Mutex
+	locales map[snowflake.ID]discord.Locale
+}
+
+func newGuildLocales() *guildLocales {
+	return &guildLocales{
+		locales: make(map[snowflake.ID]discord.Locale),
+	}
+}
+
+// guildLocale returns the locale override of the guild or an empty locale if there is none.
+func (h *Handler) guildLocale(guildID snowflake.ID) discord.Locale {
+	h.locales.mu.Lock()
+	defer h.locales.mu.Unlock()
+	if locale, ok := h.locales.locales[guildID]; ok {
+		return locale
+	}
+	override, err := h.store.GuildLocale(guildID)
+	if err != nil {
+		slog.Error("error while loading a guild locale", slog.Any("guild.id", guildID), tint.Err(err))
+		return ""
+	}
+	var locale discord.Locale
+	if override != nil {
+		locale = *override
+	}
+	h.locales.locales[guildID] = locale
+	return locale
+}
+
+// setGuildLocale caches the locale override of the guild, an empty locale removes it.
+func (h *Handler) setGuildLocale(guildID snowflake.ID, locale discord.Locale) {
+	h.locales.mu.Lock()
+	defer h.locales.mu.Unlock()
+	h.locales.locales[guildID] = locale
+}
+
+// translator returns the translator of the guild's locale override or the interaction's locale.
+func (h *Handler) translator(event localeEvent) i18n.Translator {
+	locale := event.Locale()
+	if guildID := event.GuildID(); guildID != nil {
+		if override := h.guildLocale(*guildID); override != "" {
+			locale = override
+		}
+	}
+	return h.catalogs.Translator(locale)
+}
+
+// guildTranslator returns the translator of the guild's locale override or the fallback locale for messages which
+// are not sent in response to an interaction.
+func (h *Handler) guildTranslator(guildID snowflake.ID) i18n.Translator {
+	locale := h.guildLocale(guildID)
+	if locale == "" {
+		locale = i18n.Fallback
+	}
+	return h.catalogs.Translator(locale)
+}
+
+func (h *Handler) HandleLanguage(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	guildID := *event.GuildID()
+	language := data.String("language")
+	if language == languageAuto {
+		if err := h.store.DeleteGuildLocale(guildID); err != nil {
+			return err
+		}
+		h.setGuildLocale(guildID, "")
+		slog.Info("removed a guild locale", slog.Any("guild.id", guildID), slog.Any("user.id", event.User().ID))
+		return event.CreateMessage(messageBuilder.
+			SetContent(h.catalogs.Translator(event.Locale()).T("language.auto")).
+			Build())
+	}
+	locale := discord.Locale(language)
+	if !h.catalogs.Has(locale) {
+		return event.CreateMessage(messageBuilder.
+			SetContent(h.translator(event).T("language.unknown")).
+			Build())
+	}
+	if err := h.store.PutGuildLocale(guildID, locale); err != nil {
+		return err
+	}
+	h.setGuildLocale(guildID, locale)
+	slog.Info("set a guild locale", slog.Any("guild.id", guildID), slog.Any("user.id", event.User().ID), slog.String("locale", language))
+	return event.CreateMessage(messageBuilder.
+		SetContent(h.catalogs.Translator(locale).T("language.set")).
+		Build())
+}
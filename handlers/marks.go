@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"advanced-purge/purge"
+	"context"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/lmittmann/tint"
+)
+
+func (h *Handler) HandleCollect(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	if p.StartID == 0 || p.EndID == 0 {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("range.select_first")).
+			Build())
+	}
+	if err := event.DeferCreateMessage(false); err != nil {
+		return err
+	}
+	// collecting scans the whole range, which may take minutes, so it runs in the background
+	go func() {
+		if err := h.collectMarks(event, p); err != nil {
+			p.Logger().Error("error while responding with collected marks", tint.Err(err))
+		}
+	}()
+	return nil
+}
+
+// collectMarks collects the marked messages of the purge and updates the deferred response with the result.
+func (h *Handler) collectMarks(event *handler.ComponentEvent, p *purge.Purge) error {
+	tr := h.translator(event)
+	purgeIDs, keepIDs, err := purge.CollectMarks(context.Background(), event.Client().Rest(), p.Job(), p.UserID, h.marks)
+	messageBuilder := discord.NewMessageUpdateBuilder()
+	if err != nil {
+		p.Logger().Error("error while collecting marked messages", tint.Err(err))
+		_, err = event.UpdateInteractionResponse(messageBuilder.
+			SetContent(tr.T("collect.error", err)).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.collect"), "/purge/collect"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+		return err
+	}
+	h.controller.Mark(p, purgeIDs, keepIDs)
+	_, err = event.UpdateInteractionResponse(messageBuilder.
+		SetContent(tr.T("collect.done",
+			len(purgeIDs), formatEmoji(h.marks.Purge), len(keepIDs), formatEmoji(h.marks.Keep))).
+		AddActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+	return err
+}
+
+// formatEmoji formats a reaction emoji, which is written as name:id for custom emojis, for message content.
+func formatEmoji(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
@@ -1,31 +1,182 @@
 package handlers
 
 import (
+	"advanced-purge/i18n"
+	"advanced-purge/metrics"
+	"advanced-purge/purge"
+	"errors"
+	"strings"
+
 	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
 )
 
 func (h *Handler) MiddlewareButtonUser() handler.Middleware {
 	return func(next handler.Handler) handler.Handler {
 		return func(event *handler.InteractionEvent) error {
+			tr := h.translator(event)
 			messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
-			purge := h.controller.Purge(event.Channel().ID())
+			purge := h.controller.Purge(event.Channel().ID(), event.User().ID)
 			if purge == nil {
 				return event.CreateMessage(messageBuilder.
-					SetContent("There is no purge being set up.").
+					SetContent(tr.T("middleware.no_setup")).
 					Build())
 			}
-			if purge.UserID != event.User().ID {
+			if h.controller.Running(purge) {
 				return event.CreateMessage(messageBuilder.
-					SetContent("You cannot interact with purge setups of other users.").
+					SetContent(tr.T("middleware.running")).
 					Build())
 			}
-			if purge.Running {
+			if h.controller.Pending(purge) && !isCancel(event.Interaction) {
 				return event.CreateMessage(messageBuilder.
-					SetContent("Your purge is already running.").
+					SetContent(tr.T("middleware.pending")).
+					AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 					Build())
 			}
+			h.controller.Touch(purge)
 			return next(event)
 		}
 	}
 }
+
+// MiddlewareMetrics counts the errors returned by the handlers by route.
+func (h *Handler) MiddlewareMetrics() handler.Middleware {
+	return func(next handler.Handler) handler.Handler {
+		return func(event *handler.InteractionEvent) error {
+			err := next(event)
+			if err != nil {
+				metrics.HandlerErrors.WithLabelValues(route(event)).Inc()
+			}
+			return err
+		}
+	}
+}
+
+// MiddlewareInFlight tracks the handlers in flight for the liveness check.
+func (h *Handler) MiddlewareInFlight() handler.Middleware {
+	return func(next handler.Handler) handler.Handler {
+		return func(event *handler.InteractionEvent) error {
+			defer h.inFlight.Start()()
+			return next(event)
+		}
+	}
+}
+
+// MiddlewareAuthorize checks the role policies for every interaction except approvals, whose approvers are checked by
+// MiddlewareApprover instead.
+func (h *Handler) MiddlewareAuthorize() handler.Middleware {
+	return func(next handler.Handler) handler.Handler {
+		return func(event *handler.InteractionEvent) error {
+			if isApproval(event.Interaction) {
+				return next(event)
+			}
+			if _, err := h.authorize(event.Interaction, "", 0); err != nil {
+				tr := h.translator(event)
+				return event.CreateMessage(discord.NewMessageCreateBuilder().
+					SetEphemeral(true).
+					SetContent(tr.T("middleware.denied", denial(tr, err))).
+					Build())
+			}
+			return next(event)
+		}
+	}
+}
+
+// authorize checks the guild's role policies for the interaction's member in the interaction's channel.
+func (h *Handler) authorize(interaction discord.Interaction, mode purge.Mode, count int) (int, error) {
+	return h.authorizeChannel(interaction, interaction.Channel().ID(), mode, count)
+}
+
+// authorizeChannel checks the guild's role policies for the interaction's member in the given channel, administrators
+// bypass them. Purges without a max count from the policies are limited to the default max count.
+func (h *Handler) authorizeChannel(interaction discord.Interaction, channelID snowflake.ID, mode purge.Mode, count int) (int, error) {
+	var maxCount int
+	member := interaction.Member()
+	if interaction.GuildID() != nil && member != nil && !member.Permissions.Has(discord.PermissionAdministrator) {
+		var err error
+		if maxCount, err = h.policies.Authorize(*interaction.GuildID(), channelID, member.RoleIDs, mode, count); err != nil {
+			return 0, err
+		}
+	}
+	if maxCount == 0 {
+		maxCount = h.limits.MaxCount
+	}
+	if count > 0 && maxCount > 0 && count > maxCount {
+		return 0, &purge.PolicyError{Reason: "policy.max_count", Args: []any{maxCount}}
+	}
+	return maxCount, nil
+}
+
+// authorizeTarget checks the member's Manage Messages permission and the role policies in a channel targeted by a
+// purge which runs outside of the interaction's channel. It returns the max count like authorizeChannel.
+func (h *Handler) authorizeTarget(interaction discord.Interaction, permissions *permissionResolver, channelID snowflake.ID, mode purge.Mode) (int, error) {
+	channelPermissions, err := permissions.channel(channelID)
+	if err != nil {
+		return 0, err
+	}
+	if !channelPermissions.Has(discord.PermissionManageMessages) {
+		return 0, &purge.PolicyError{Reason: "policy.manage_messages"}
+	}
+	return h.authorizeChannel(interaction, channelID, mode, 0)
+}
+
+// denial describes why the policies denied a purge in the translator's language, one reason per line. Other errors
+// are described as they are.
+func denial(tr i18n.Translator, err error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var reasons []string
+		for _, err := range joined.Unwrap() {
+			reasons = append(reasons, denial(tr, err))
+		}
+		return strings.Join(reasons, "\n")
+	}
+	var policyErr *purge.PolicyError
+	if !errors.As(err, &policyErr) {
+		return err.Error()
+	}
+	reason := tr.T(policyErr.Reason, policyErr.Args...)
+	if policyErr.Policy == "" {
+		return reason
+	}
+	return tr.T("policy.blocked", policyErr.Policy, reason)
+}
+
+// isCancel reports whether the interaction cancels a purge setup.
+func isCancel(interaction discord.Interaction) bool {
+	component, ok := interaction.(discord.ComponentInteraction)
+	return ok && component.Data.CustomID() == "/purge/cancel"
+}
+
+// isApproval reports whether the interaction approves or rejects a purge.
+func isApproval(interaction discord.Interaction) bool {
+	component, ok := interaction.(discord.ComponentInteraction)
+	return ok && strings.HasPrefix(component.Data.CustomID(), "/purge/approval/")
+}
+
+// route returns the path of the handled interaction with the values of its variables replaced by their names.
+func route(event *handler.InteractionEvent) string {
+	var path string
+	switch interaction := event.Interaction.(type) {
+	case discord.ApplicationCommandInteraction:
+		if data, ok := interaction.Data.(discord.SlashCommandInteractionData); ok {
+			path = data.CommandPath()
+		} else {
+			path = "/" + interaction.Data.CommandName()
+		}
+	case discord.ComponentInteraction:
+		path = interaction.Data.CustomID()
+	case discord.ModalSubmitInteraction:
+		path = interaction.Data.CustomID
+	}
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		for name, value := range event.Vars {
+			if part != "" && part == value {
+				parts[i] = "{" + name + "}"
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"slices"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// permissionResolver resolves the permissions of an interaction's member in other channels of the guild, as the
+// interaction only carries the member's permissions in the channel it was used in.
+type permissionResolver struct {
+	client      rest.Rest
+	member      discord.ResolvedMember
+	permissions discord.Permissions
+	channels    map[snowflake.ID]discord.GuildChannel
+}
+
+// newPermissionResolver returns a resolver for the interaction's member. The given channels are used instead of
+// fetching them, other channels are fetched once they are needed.
+func newPermissionResolver(client rest.Rest, interaction discord.Interaction, channels []discord.GuildChannel) (*permissionResolver, error) {
+	member := interaction.Member()
+	resolver := &permissionResolver{
+		client:   client,
+		member:   *member,
+		channels: make(map[snowflake.ID]discord.GuildChannel, len(channels)),
+	}
+	for _, channel := range channels {
+		resolver.channels[channel.ID()] = channel
+	}
+	if member.Permissions.Has(discord.PermissionAdministrator) {
+		resolver.permissions = discord.PermissionsAll
+		return resolver, nil
+	}
+	guild, err := client.GetGuild(member.GuildID, false)
+	if err != nil {
+		return nil, err
+	}
+	if guild.OwnerID == member.User.ID {
+		resolver.permissions = discord.PermissionsAll
+		return resolver, nil
+	}
+	for _, role := range guild.Roles {
+		if role.ID == guild.ID || slices.Contains(member.RoleIDs, role.ID) {
+			resolver.permissions = resolver.permissions.Add(role.Permissions)
+		}
+	}
+	if resolver.permissions.Has(discord.PermissionAdministrator) {
+		resolver.permissions = discord.PermissionsAll
+	}
+	return resolver, nil
+}
+
+// channel returns the member's permissions in the channel with the channel's overwrites applied. Threads use the
+// overwrites of their parent channel.
+func (r *permissionResolver) channel(channelID snowflake.ID) (discord.Permissions, error) {
+	if r.permissions.Has(discord.PermissionAdministrator) {
+		return r.permissions, nil
+	}
+	channel, ok := r.channels[channelID]
+	if !ok {
+		fetched, err := r.client.GetChannel(channelID)
+		if err != nil {
+			return 0, err
+		}
+		if channel, ok = fetched.(discord.GuildChannel); !ok {
+			return 0, nil
+		}
+		r.channels[channelID] = channel
+	}
+	if thread, ok := channel.(discord.GuildThread); ok {
+		return r.channel(*thread.ParentID())
+	}
+
+	permissions := r.permissions
+	overwrites := channel.PermissionOverwrites()
+	if overwrite, ok := overwrites.Role(channel.GuildID()); ok {
+		permissions = permissions.Remove(overwrite.Deny).Add(overwrite.Allow)
+	}
+	var allow, deny discord.Permissions
+	for _, roleID := range r.member.RoleIDs {
+		if overwrite, ok := overwrites.Role(roleID); ok && roleID != channel.GuildID() {
+			allow = allow.Add(overwrite.Allow)
+			deny = deny.Add(overwrite.Deny)
+		}
+	}
+	permissions = permissions.Remove(deny).Add(allow)
+	if overwrite, ok := overwrites.Member(r.member.User.ID); ok {
+		permissions = permissions.Remove(overwrite.Deny).Add(overwrite.Allow)
+	}
+	return permissions, nil
+}
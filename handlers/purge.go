@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"advanced-purge/purge"
+	"context"
 	"errors"
 	"log/slog"
-	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/disgoorg/disgo/bot"
 	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/disgo/handler"
 	"github.com/disgoorg/disgo/rest"
@@ -13,417 +16,591 @@ import (
 	"github.com/lmittmann/tint"
 )
 
-var (
-	endMessageFunc = func(endID snowflake.ID) func(discord.Message) bool {
-		return func(message discord.Message) bool {
-			return message.ID == endID
-		}
-	}
-)
-
 func (h *Handler) HandlePurge(_ discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
-	purge := h.controller.Purge(event.Channel().ID())
-	if purge == nil {
-		h.controller.CreatePurge(event.Channel().ID(), event.User().ID)
-
-		return event.CreateMessage(messageBuilder.
-			SetContent("Would you like to run a simple or an advanced purge?").
-			AddActionRow(
-				discord.NewPrimaryButton("Simple purge", "/purge/simple"),
-				discord.NewPrimaryButton("Advanced purge (range)", "/purge/advanced"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
-			Build())
+	channelID := event.Channel().ID()
+	if own := h.controller.Purge(channelID, event.User().ID); own != nil {
+		if err := event.CreateMessage(messageBuilder.
+			SetContent(tr.T("setup.exists")).
+			Build()); err != nil {
+			return err
+		}
+		if h.canTakeOver(event.ApplicationCommandInteraction) {
+			return h.offerTakeOver(event, own)
+		}
+		return nil
 	}
-	if purge.UserID == event.User().ID {
+	p, err := h.controller.CreatePurge(*event.GuildID(), channelID, event.Channel().Type(), event.User().ID)
+	if err != nil {
 		return event.CreateMessage(messageBuilder.
-			SetContent("You already have a purge setup running.").
+			SetContent(tr.T("setup.unsupported")).
 			Build())
 	}
-	return event.CreateMessage(messageBuilder.
-		SetContentf("This channel already has a purge setup running by <@%d>.", purge.UserID).
-		Build())
+
+	content := "setup.prompt"
+	if purge.IsThread(p.ChannelType) {
+		content = "setup.prompt_thread"
+	}
+	if err := event.CreateMessage(messageBuilder.
+		SetContent(tr.T(content)).
+		AddContainerComponents(setupActionRow(tr, p)).
+		Build()); err != nil {
+		return err
+	}
+	message, err := event.GetInteractionResponse()
+	if err != nil {
+		return err
+	}
+	h.controller.SetPromptID(p, message.ID)
+
+	if h.canTakeOver(event.ApplicationCommandInteraction) {
+		return h.offerTakeOver(event, p)
+	}
+	return nil
 }
 
 func (h *Handler) HandleSimple(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
-	return nil
+	tr := h.translator(event)
+	if _, err := h.authorize(event.ComponentInteraction, purge.ModeSimple, 0); err != nil {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("simple.denied", denial(tr, err))).
+			Build())
+	}
+	return event.Modal(discord.NewModalCreateBuilder().
+		SetTitle(tr.T("simple.title")).
+		SetCustomID("/purge/amount").
+		AddActionRow(
+			discord.NewShortTextInput("amount", tr.T("simple.amount")).
+				WithRequired(true).
+				WithMaxLength(4)).
+		Build())
 }
 
 func (h *Handler) HandleAdvanced(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	if _, err := h.authorize(event.ComponentInteraction, purge.ModeAdvanced, 0); err != nil {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("advanced.denied", denial(tr, err))).
+			Build())
+	}
 	return event.Modal(discord.NewModalCreateBuilder().
-		SetTitle("Enter how many messages can be purged at once").
-		SetCustomID("purge").
+		SetTitle(tr.T("advanced.title")).
+		SetCustomID("/purge/limit").
 		AddActionRow(
-			discord.NewShortTextInput("limit", "Limit of messages to purge at once").
+			discord.NewShortTextInput("limit", tr.T("advanced.limit")).
 				WithRequired(true).
-				WithMaxLength(3)).
+				WithMaxLength(3).
+				WithValue(strconv.Itoa(h.limits.BulkLimit))).
 		Build())
 }
 
 func (h *Handler) HandleCancel(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
-	h.controller.RemovePurge(event.Channel().ID())
+	tr := h.translator(event)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	if approval, ok := h.controller.Approval(p); ok && approval.RequestID != 0 {
+		if _, err := event.Client().Rest().UpdateMessage(approval.RequestChannelID, approval.RequestID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("setup.canceled_request")).
+			ClearContainerComponents().
+			Build()); err != nil {
+			p.Logger().Error("error while withdrawing an approval request", tint.Err(err))
+		}
+	}
+	h.controller.RemovePurge(event.Channel().ID(), event.User().ID)
 	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
-		SetContent("Alright, purge has been canceled.").
+		SetContent(tr.T("setup.canceled")).
 		ClearContainerComponents().
 		Build())
 }
 
 func (h *Handler) HandleLimit(event *handler.ModalEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
 	amount := event.Data.Text("limit")
 	i, err := strconv.Atoi(amount)
 	if err != nil || i <= 1 || i > 100 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Provide a number between 2 and 100.").
+			SetContent(tr.T("advanced.invalid")).
+			Build())
+	}
+	maxCount, err := h.authorize(event.ModalSubmitInteraction, purge.ModeAdvanced, 0)
+	if err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("advanced.denied", denial(tr, err))).
 			Build())
 	}
-	h.controller.SetBulkLimit(event.Channel().ID(), i)
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	h.controller.SetMode(p, purge.ModeAdvanced, maxCount)
+	h.controller.SetBulkLimit(p, i)
 
 	return event.CreateMessage(messageBuilder.
-		SetContent(`Set a starting point by right clicking a message and hitting "**Set as start**".`).
-		AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+		SetContent(tr.T("advanced.start")).
+		AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
+func (h *Handler) HandleAmount(event *handler.ModalEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	amount, err := strconv.Atoi(event.Data.Text("amount"))
+	if err != nil || amount < 1 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("simple.invalid")).
+			Build())
+	}
+	maxCount, err := h.authorize(event.ModalSubmitInteraction, purge.ModeSimple, amount)
+	if err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("simple.denied_amount", amount, denial(tr, err))).
+			Build())
+	}
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	h.controller.SetMode(p, purge.ModeSimple, maxCount)
+	h.controller.SetAmount(p, amount)
+	overlapping := h.controller.Overlapping(p)
+	for _, other := range overlapping {
+		if h.controller.Running(other) {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("simple.overlap", other.UserID)).
+				Build())
+		}
+	}
+	if len(overlapping) > 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("simple.overlap_setups", mentionOwners(overlapping))).
+			Build())
+	}
+	return h.confirm(event, p)
+}
+
 func (h *Handler) HandleStart(data discord.MessageCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	jumpURL := data.TargetMessage().JumpURL()
 	if purge.StartID == 0 {
 		if ok := h.controller.SetStartID(purge, data.TargetID()); !ok {
 			return event.CreateMessage(messageBuilder.
-				SetContent("Message cannot be older than 2 weeks.").
-				AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				SetContent(tr.T("range.too_old")).
+				AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 				Build())
 		}
 		return event.CreateMessage(messageBuilder.
-			SetContentf(`Start message has been set to %s. Now, specify a range and select your end message by right clicking a message and hitting "**Set as end**".`, jumpURL).
-			AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			SetContent(tr.T("start.set", jumpURL)).
+			AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	if purge.StartID == data.TargetID() {
 		return event.CreateMessage(messageBuilder.
-			SetContent("This message already is the start message.").
-			AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			SetContent(tr.T("start.same")).
+			AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("You have already selected a [start message](%s). Do you want to use [this message](%s) as the start instead?",
+		SetContent(tr.T("start.replace",
 			discord.MessageURL(*event.GuildID(), channelID, purge.StartID),
-			jumpURL).
+			jumpURL)).
 		AddActionRow(
-			discord.NewPrimaryButton("No, keep the previous one.", "/purge/start-change/keep"),
-			discord.NewPrimaryButton("Yes, change start to this one.", "/purge/start-change/"+data.TargetID().String()),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("start.keep_button"), "/purge/start-change/keep"),
+			discord.NewPrimaryButton(tr.T("start.change_button"), "/purge/start-change/"+data.TargetID().String()),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleStartKeep(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	return event.CreateMessage(discord.NewMessageCreateBuilder().
-		SetContentf("Alright, keeping [the current message](%s) as the start.", discord.MessageURL(*event.GuildID(), channelID, purge.StartID)).
+		SetContent(tr.T("start.kept", discord.MessageURL(*event.GuildID(), channelID, purge.StartID))).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleStartChange(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	messageBuilder := discord.NewMessageCreateBuilder()
 	newID := snowflake.MustParse(event.Vars["new-id"])
 	if newID == purge.StartID {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Cannot set the start message to the end message.").
+			SetContent(tr.T("start.is_end")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	if ok := h.controller.SetStartID(purge, newID); !ok {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Message cannot be older than 2 weeks.").
-			AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			SetContent(tr.T("range.too_old")).
+			AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("Alright, start message has been set to [this message](%s).", discord.MessageURL(*event.GuildID(), channelID, purge.StartID)).
+		SetContent(tr.T("start.changed", discord.MessageURL(*event.GuildID(), channelID, purge.StartID))).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleEnd(data discord.MessageCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	if purge.StartID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the start message first.").
+			SetContent(tr.T("range.start_first")).
 			Build())
 	}
 	jumpURL := data.TargetMessage().JumpURL()
 	if purge.EndID == 0 {
 		if data.TargetID() == purge.StartID {
 			return event.CreateMessage(messageBuilder.
-				SetContent("Cannot set the end message to the start message.").
-				AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				SetContent(tr.T("end.is_start")).
+				AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 				Build())
 		}
 		if ok := h.controller.SetEndID(purge, data.TargetID()); !ok {
 			return event.CreateMessage(messageBuilder.
-				SetContent("Messages cannot be older than 2 weeks.").
-				AddActionRow(discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				SetContent(tr.T("range.too_old_range")).
+				AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 				Build())
 		}
 		return event.CreateMessage(messageBuilder.
-			SetContentf("End message has been set to %s.", jumpURL).
+			SetContent(tr.T("end.set", jumpURL, formatEmoji(h.marks.Purge), formatEmoji(h.marks.Keep))).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewSecondaryButton(tr.T("button.collect"), "/purge/collect"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			AddContainerComponents(exclusionActionRow(tr)).
 			Build())
 	}
 	if purge.EndID == data.TargetID() {
 		return event.CreateMessage(messageBuilder.
-			SetContent("This message already is the end message.").
+			SetContent(tr.T("end.same")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("You have already selected an [end message](%s). Do you want to use [this message](%s) as the end instead?",
+		SetContent(tr.T("end.replace",
 			discord.MessageURL(*event.GuildID(), channelID, purge.StartID),
-			jumpURL).
+			jumpURL)).
 		AddActionRow(
-			discord.NewPrimaryButton("No, keep the previous one.", "/purge/end-change/keep"),
-			discord.NewPrimaryButton("Yes, change end to this one.", "/purge/end-change/"+data.TargetID().String()),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("end.keep_button"), "/purge/end-change/keep"),
+			discord.NewPrimaryButton(tr.T("end.change_button"), "/purge/end-change/"+data.TargetID().String()),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleEndKeep(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	return event.CreateMessage(discord.NewMessageCreateBuilder().
-		SetContentf("Alright, keeping [the current message](%s) as the end.", discord.MessageURL(*event.GuildID(), channelID, purge.EndID)).
+		SetContent(tr.T("end.kept", discord.MessageURL(*event.GuildID(), channelID, purge.EndID))).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleEndChange(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
 	channelID := event.Channel().ID()
-	purge := h.controller.Purge(channelID)
+	purge := h.controller.Purge(channelID, event.User().ID)
 	messageBuilder := discord.NewMessageCreateBuilder()
 	newID := snowflake.MustParse(event.Vars["new-id"])
 	if newID == purge.StartID {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Cannot set the end message to the start message.").
+			SetContent(tr.T("end.is_start")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	if ok := h.controller.SetEndID(purge, newID); !ok {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Message cannot be older than 2 weeks.").
+			SetContent(tr.T("range.too_old")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("Alright, end message has been set to [this message](%s).", discord.MessageURL(*event.GuildID(), channelID, purge.EndID)).
+		SetContent(tr.T("end.changed", discord.MessageURL(*event.GuildID(), channelID, purge.EndID))).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleExclude(data discord.MessageCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
-	purge := h.controller.Purge(event.Channel().ID())
+	purge := h.controller.Purge(event.Channel().ID(), event.User().ID)
 	if purge == nil {
 		return event.CreateMessage(messageBuilder.
-			SetContent("There is no purge being set up.").
+			SetContent(tr.T("setup.none")).
 			Build())
 	}
 	if purge.StartID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the start message first.").
+			SetContent(tr.T("range.start_first")).
 			Build())
 	}
 	if purge.EndID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the end message first.").
+			SetContent(tr.T("range.end_first")).
 			Build())
 	}
 	if data.TargetID() == purge.StartID {
 		return event.CreateMessage(messageBuilder.
-			SetContent("You cannot exclude the start message.").
+			SetContent(tr.T("exclude.start")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	if data.TargetID() == purge.EndID {
 		return event.CreateMessage(messageBuilder.
-			SetContent("You cannot exclude the end message.").
+			SetContent(tr.T("exclude.end")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	if (!purge.Forwards && (data.TargetID() > purge.StartID || data.TargetID() < purge.EndID)) || (purge.Forwards && (data.TargetID() < purge.StartID || data.TargetID() > purge.EndID)) {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Message is out of the specified range.").
+			SetContent(tr.T("exclude.out_of_range")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 
 	jumpURL := data.TargetMessage().JumpURL()
 	if ok := h.controller.ExcludeMessage(purge, data.TargetID()); !ok {
 		return event.CreateMessage(messageBuilder.
-			SetContentf("[This message](%s) is already excluded.", jumpURL).
+			SetContent(tr.T("exclude.already", jumpURL)).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("Alright, [this message](%s) has been excluded.", jumpURL).
+		SetContent(tr.T("exclude.done", jumpURL)).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		AddContainerComponents(exclusionActionRow(tr)).
 		Build())
 }
 
 func (h *Handler) HandleInclude(data discord.MessageCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
-	purge := h.controller.Purge(event.Channel().ID())
+	purge := h.controller.Purge(event.Channel().ID(), event.User().ID)
 	if purge == nil {
 		return event.CreateMessage(messageBuilder.
-			SetContent("There is no purge being set up.").
+			SetContent(tr.T("setup.none")).
 			Build())
 	}
 	if purge.StartID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the start message first.").
+			SetContent(tr.T("range.start_first")).
 			Build())
 	}
 	if purge.EndID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the end message first.").
+			SetContent(tr.T("range.end_first")).
 			Build())
 	}
 
-	if ok := h.controller.IncludeMessage(purge, data.TargetID()); !ok {
+	if ok := h.controller.IncludeMessage(purge, data.TargetMessage()); !ok {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Messages have to be excluded to include them back.").
+			SetContent(tr.T("include.not_excluded")).
 			AddActionRow(
-				discord.NewPrimaryButton("Run purge", "/purge/run"),
-				discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+				discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 			Build())
 	}
 	return event.CreateMessage(messageBuilder.
-		SetContentf("Alright, [this message](%s) will not be excluded.", data.TargetMessage().JumpURL()).
+		SetContent(tr.T("include.done", data.TargetMessage().JumpURL())).
 		AddActionRow(
-			discord.NewPrimaryButton("Run purge", "/purge/run"),
-			discord.NewDangerButton("Cancel purge", "/purge/cancel")).
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 		Build())
 }
 
 func (h *Handler) HandleRun(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
 	messageBuilder := discord.NewMessageCreateBuilder()
-	purge := h.controller.Purge(event.Channel().ID())
+	purge := h.controller.Purge(event.Channel().ID(), event.User().ID)
 	if purge == nil {
 		return event.CreateMessage(messageBuilder.
-			SetContent("There is no purge being set up.").
+			SetContent(tr.T("setup.none")).
 			Build())
 	}
 	if purge.StartID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the start message first.").
+			SetContent(tr.T("range.start_first")).
 			Build())
 	}
 	if purge.EndID == 0 {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Select the end message first.").
+			SetContent(tr.T("range.end_first")).
 			Build())
 	}
-	if purge.Running {
+	if h.controller.Running(purge) {
 		return event.CreateMessage(messageBuilder.
-			SetContent("Your purge is already running.").
+			SetContent(tr.T("run.already_running")).
 			Build())
 	}
-	purge.Running = true
-	go func() {
-		client := event.Client().Rest()
-		page := client.GetMessagesPage(event.Channel().ID(), purge.StartID, purge.BulkLimit)
-		pageFunc := page.Previous
-		if purge.Forwards {
-			pageFunc = page.Next
+	for _, other := range h.controller.Overlapping(purge) {
+		if h.controller.Running(other) {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("run.overlap_running", other.UserID)).
+				AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+				Build())
 		}
-		excluded := purge.Excluded()
-		var total int
-		for i := 0; ; i++ {
-			if !pageFunc() {
-				if !errors.Is(page.Err, rest.ErrNoMorePages) {
-					_, err := event.CreateFollowupMessage(messageBuilder.
-						SetContentf("There was an error while purging: **%s**.", page.Err.Error()).
-						Build())
-					if err != nil {
-						slog.Error("error while responding with a purge error", tint.Err(err))
-					}
-					slog.Error("error while running a purge", slog.Any("channel.id", event.Channel().ID()), tint.Err(page.Err))
-				}
-				return
-			}
-			messageIDs := make([]snowflake.ID, 0, len(page.Items))
-			for _, message := range page.Items {
-				if (purge.Forwards && message.ID > purge.EndID) || (!purge.Forwards && message.ID < purge.EndID) { // ignore if fetched but over the end message
-					continue
-				}
-				if !slices.Contains(excluded, message.ID) {
-					messageIDs = append(messageIDs, message.ID)
-				}
-			}
-			if err := client.BulkDeleteMessages(event.Channel().ID(), messageIDs); err != nil {
-				if _, err := event.CreateFollowupMessage(messageBuilder.
-					SetContentf("There was an error while trying to bulk delete: **%s**", err.Error()).
-					Build()); err != nil {
-					slog.Error("error while responding with a bulk delete error", tint.Err(err))
-				}
-				slog.Error("error while running a bulk delete", slog.Any("channel.id", event.Channel().ID()), tint.Err(err))
-				return
-			}
-			total += len(messageIDs)
-			_, err := event.CreateFollowupMessage(messageBuilder.
-				SetContentf("Purged bulk **%d**.. (messages purged so far: **%d**)", i+1, total).
+	}
+	if overlapping := h.controller.Overlapping(purge); len(overlapping) > 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("run.overlap_setups", mentionOwners(overlapping))).
+			AddActionRow(
+				discord.NewPrimaryButton(tr.T("button.merge"), "/purge/merge"),
+				discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+	}
+	return h.confirm(event, purge)
+}
+
+func (h *Handler) HandleMerge(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	overlapping := h.controller.Overlapping(p)
+	for _, other := range overlapping {
+		if h.controller.Running(other) {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("run.overlap_running", other.UserID)).
+				AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
 				Build())
-			if err != nil {
-				slog.Error("error while responding with a purge bulk update", tint.Err(err))
-				return
-			}
+		}
+	}
+	if err := h.controller.Merge(p, overlapping...); err != nil {
+		content := tr.T("range.too_old_range")
+		if errors.Is(err, purge.ErrSetupBusy) {
+			content = tr.T("run.overlap_busy")
+		}
+		return event.CreateMessage(messageBuilder.
+			SetContent(content).
+			AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+			Build())
+	}
+	p.Logger().Info("merged overlapping purge setups", slog.Int("merged", len(overlapping)))
+	for _, other := range overlapping {
+		if other.PromptID == 0 {
+			continue
+		}
+		if _, err := event.Client().Rest().UpdateMessage(other.ChannelID, other.PromptID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("run.merged", other.UserID, p.UserID)).
+			ClearContainerComponents().
+			Build()); err != nil {
+			other.Logger().Error("error while closing a merged purge setup", tint.Err(err))
+		}
+	}
+	return h.confirm(event, p)
+}
+
+// mentionOwners returns a comma separated list of mentions of the purges' owners.
+func mentionOwners(purges []*purge.Purge) string {
+	userIDs := make([]snowflake.ID, len(purges))
+	for i, p := range purges {
+		userIDs[i] = p.UserID
+	}
+	return mentionUsers(userIDs)
+}
+
+// mentionUsers returns a comma separated list of mentions of the users.
+func mentionUsers(userIDs []snowflake.ID) string {
+	mentions := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		mentions[i] = discord.UserMention(userID)
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// purgeEvent is implemented by all interaction events which can start a purge.
+type purgeEvent interface {
+	Client() bot.Client
+	GuildID() *snowflake.ID
+	Locale() discord.Locale
+	CreateMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) error
+	DeferCreateMessage(ephemeral bool, opts ...rest.RequestOpt) error
+	UpdateInteractionResponse(messageUpdate discord.MessageUpdate, opts ...rest.RequestOpt) (*discord.Message, error)
+	CreateFollowupMessage(messageCreate discord.MessageCreate, opts ...rest.RequestOpt) (*discord.Message, error)
+}
 
-			if slices.ContainsFunc(page.Items, endMessageFunc(purge.EndID)) {
-				_, err := event.CreateFollowupMessage(messageBuilder.
-					SetContentf("All messages have been purged. Total count: **%d**", total).
-					Build())
-				if err != nil {
-					slog.Error("error while responding with a purge end update", tint.Err(err))
-				}
-				h.controller.RemovePurge(event.Channel().ID())
-				return
+// run executes the purge in the background and reports its progress. The interaction response has to be deferred.
+func (h *Handler) run(event purgeEvent, p *purge.Purge) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	execute := h.index.Execute
+	progress := "run.progress"
+	if p.Mode == purge.ModeReactions {
+		execute = p.Reactions.Execute
+		progress = "run.progress_reactions"
+	}
+	record := p.Record()
+	record.Filters = describeExclusions(p)
+	job := p.Job()
+	h.controller.SetRunning(p, true)
+	ctx, done := h.controller.StartRun(context.Background())
+	go func() {
+		defer done()
+		_, err := h.recorded(execute, record)(ctx, event.Client().Rest(), job, func(batch int, total int) error {
+			_, err := event.CreateFollowupMessage(messageBuilder.
+				SetContent(tr.T(progress, batch, total)).
+				Build())
+			return err
+		})
+		if errors.Is(context.Cause(ctx), purge.ErrInterrupted) {
+			h.interrupt(event, p, *record, job)
+			return
+		}
+		if err != nil {
+			if _, err := event.CreateFollowupMessage(messageBuilder.
+				SetContent(tr.T("run.error", err.Error())).
+				Build()); err != nil {
+				record.Logger().Error("error while responding with a purge error", tint.Err(err))
 			}
+			h.controller.SetRunning(p, false)
+			return
 		}
+		h.finish(event, p, record)
 	}()
-	return event.CreateMessage(messageBuilder.
-		SetContent("Running purge..").
+	_, err := event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("run.running")).
+		Build())
+	return err
+}
+
+func (h *Handler) finish(event purgeEvent, p *purge.Purge, record *purge.Record) {
+	content := "run.done"
+	if p.Mode == purge.ModeReactions {
+		content = "run.done_reactions"
+	}
+	_, err := event.CreateFollowupMessage(discord.NewMessageCreateBuilder().
+		SetContent(h.translator(event).T(content, record.Count)).
 		Build())
+	if err != nil {
+		record.Logger().Error("error while responding with a purge end update", tint.Err(err))
+	}
+	h.controller.RemovePurge(p.ChannelID, p.UserID)
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var (
+	snowflakePattern = regexp.MustCompile(`\d{17,20}`)
+)
+
+// parseSnowflakes returns all IDs in the given text, which are usually user or channel mentions.
+func parseSnowflakes(text string) []snowflake.ID {
+	var ids []snowflake.ID
+	for _, match := range snowflakePattern.FindAllString(text, -1) {
+		id, err := snowflake.Parse(match)
+		if err == nil && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (h *Handler) HandleRaid(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	window, ok := parseWindow(data.String("window"))
+	if !ok {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("window.invalid")).
+			Build())
+	}
+	client := event.Client().Rest()
+	channelIDs := parseSnowflakes(data.String("channels"))
+	var channels []discord.GuildChannel
+	if category, ok := data.OptChannel("category"); ok {
+		var err error
+		if channels, err = client.GetGuildChannels(*event.GuildID()); err != nil {
+			return err
+		}
+		for _, channel := range channels {
+			if parentID := channel.ParentID(); parentID != nil && *parentID == category.ID && purge.SupportsMessages(channel.Type()) && !slices.Contains(channelIDs, channel.ID()) {
+				channelIDs = append(channelIDs, channel.ID())
+			}
+		}
+	}
+	if len(channelIDs) == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("raid.no_channels")).
+			Build())
+	}
+	permissions, err := newPermissionResolver(client, event.ApplicationCommandInteraction, channels)
+	if err != nil {
+		return err
+	}
+	maxCounts, denied := h.authorizeTargets(tr, event.ApplicationCommandInteraction, permissions, channelIDs, purge.ModeRaid)
+	if len(denied) > 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("raid.denied", strings.Join(denied, "\n"))).
+			Build())
+	}
+
+	authorIDs := parseSnowflakes(data.String("authors"))
+	now := time.Now()
+	job := purge.Job{
+		StartID:   snowflake.New(now),
+		EndID:     snowflake.New(now.Add(-window)),
+		AuthorIDs: authorIDs,
+	}
+	record := purge.NewRecord(*event.GuildID(), 0, event.User().ID, purge.ModeRaid)
+	record.Logger().Info("starting a raid purge", slog.Int("channels", len(channelIDs)), slog.Duration("window", window))
+	return h.purgeChannels(event, tr.T("raid.name"), record, nil, limitJobs(channelJobs(job, channelIDs), maxCounts))
+}
+
+// authorizeTargets checks each of the channels with authorizeTarget and returns the max count per channel and the
+// reasons why channels were denied.
+func (h *Handler) authorizeTargets(tr i18n.Translator, interaction discord.Interaction, permissions *permissionResolver, channelIDs []snowflake.ID, mode purge.Mode) ([]int, []string) {
+	var (
+		maxCounts = make([]int, len(channelIDs))
+		denied    []string
+	)
+	for i, channelID := range channelIDs {
+		maxCount, err := h.authorizeTarget(interaction, permissions, channelID, mode)
+		if err != nil {
+			denied = append(denied, fmt.Sprintf("%s: %s", discord.ChannelMention(channelID), denial(tr, err)))
+		}
+		maxCounts[i] = maxCount
+	}
+	return maxCounts, denied
+}
+
+// limitJobs sets the max count of each job to the one at the same index.
+func limitJobs(jobs []purge.Job, maxCounts []int) []purge.Job {
+	for i := range jobs {
+		jobs[i].MaxCount = maxCounts[i]
+	}
+	return jobs
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"advanced-purge/purge"
+	"regexp"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+)
+
+var customEmojiPattern = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+
+// parseEmoji parses a unicode emoji or a custom emoji mention into the form used for reactions.
+func parseEmoji(text string) string {
+	text = strings.TrimSpace(text)
+	if match := customEmojiPattern.FindStringSubmatch(text); match != nil {
+		return match[1] + ":" + match[2]
+	}
+	return text
+}
+
+func (h *Handler) HandleReactions(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	if _, err := h.authorize(event.ComponentInteraction, purge.ModeReactions, 0); err != nil {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("reactions.denied", denial(tr, err))).
+			Build())
+	}
+	return event.Modal(discord.NewModalCreateBuilder().
+		SetTitle(tr.T("reactions.title")).
+		SetCustomID("/purge/reactions").
+		AddActionRow(
+			discord.NewShortTextInput("emoji", tr.T("reactions.emoji")).
+				WithRequired(false).
+				WithMaxLength(100)).
+		AddActionRow(
+			discord.NewShortTextInput("user", tr.T("reactions.user")).
+				WithRequired(false).
+				WithMaxLength(30)).
+		Build())
+}
+
+func (h *Handler) HandleReactionsFilter(event *handler.ModalEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	filter := purge.ReactionFilter{
+		Emoji: parseEmoji(event.Data.Text("emoji")),
+	}
+	if user := event.Data.Text("user"); user != "" {
+		if filter.UserID = parseMessageID(user); filter.UserID == 0 {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("reactions.invalid_user")).
+				Build())
+		}
+	}
+	maxCount, err := h.authorize(event.ModalSubmitInteraction, purge.ModeReactions, 0)
+	if err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("reactions.denied", denial(tr, err))).
+			Build())
+	}
+	p := h.controller.Purge(event.Channel().ID(), event.User().ID)
+	h.controller.SetMode(p, purge.ModeReactions, maxCount)
+	h.controller.SetReactions(p, filter)
+
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("advanced.start")).
+		AddActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/lmittmann/tint"
+)
+
+const (
+	retentionInterval     = time.Minute
+	retentionMinEvery     = 5 * time.Minute
+	retentionDefaultEvery = time.Hour
+)
+
+func (h *Handler) HandleRetentionSet(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	channel := data.Channel("channel")
+	retention := purge.Retention{
+		GuildID:   *event.GuildID(),
+		ChannelID: channel.ID,
+		UserID:    event.User().ID,
+		MaxCount:  data.Int("max-count"),
+		Every:     retentionDefaultEvery,
+	}
+	if text, ok := data.OptString("max-age"); ok {
+		maxAge, err := parseDuration(text)
+		if err != nil || maxAge <= 0 {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("retention.invalid_max_age")).
+				Build())
+		}
+		retention.MaxAge = maxAge
+	}
+	if retention.MaxAge == 0 && retention.MaxCount == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("retention.missing_limits")).
+			Build())
+	}
+	if text, ok := data.OptString("every"); ok {
+		every, err := parseDuration(text)
+		if err != nil || every < retentionMinEvery {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("retention.invalid_every", retentionMinEvery)).
+				Build())
+		}
+		retention.Every = every
+	}
+	permissions, err := newPermissionResolver(event.Client().Rest(), event.ApplicationCommandInteraction, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := h.authorizeTarget(event.ApplicationCommandInteraction, permissions, channel.ID, purge.ModeRetention); err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("retention.set_denied", discord.ChannelMention(channel.ID), denial(tr, err))).
+			Build())
+	}
+	// the rule has never run, so it is enforced on the next check
+	if err := h.store.PutRetention(retention); err != nil {
+		return err
+	}
+	slog.Info("set a retention rule", slog.Any("channel.id", retention.ChannelID), slog.Any("user.id", retention.UserID), slog.Duration("max_age", retention.MaxAge), slog.Int("max_count", retention.MaxCount))
+
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("retention.set", describeRetention(tr, retention))).
+		Build())
+}
+
+func (h *Handler) HandleRetentionList(_ discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	retentions, err := h.store.Retentions()
+	if err != nil {
+		return err
+	}
+	var lines []string
+	for _, retention := range retentions {
+		if retention.GuildID != *event.GuildID() {
+			continue
+		}
+		line := describeRetention(tr, retention)
+		if !retention.LastRun.IsZero() {
+			line = tr.T("retention.last_run", line, discord.FormattedTimestampMention(retention.LastRun.Unix(), discord.TimestampStyleRelative))
+		}
+		lines = append(lines, "- "+line)
+	}
+	if len(lines) == 0 {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("retention.none")).
+			Build())
+	}
+	return event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetContent(joinLines(tr.T("retention.title"), lines)).
+		Build())
+}
+
+func (h *Handler) HandleRetentionRemove(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	channel := data.Channel("channel")
+	retention, err := h.store.Retention(channel.ID)
+	if err != nil {
+		return err
+	}
+	if retention == nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("retention.missing", discord.ChannelMention(channel.ID))).
+			Build())
+	}
+	permissions, err := newPermissionResolver(event.Client().Rest(), event.ApplicationCommandInteraction, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := h.authorizeTarget(event.ApplicationCommandInteraction, permissions, channel.ID, purge.ModeRetention); err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("retention.remove_denied", discord.ChannelMention(channel.ID), denial(tr, err))).
+			Build())
+	}
+	if err := h.store.DeleteRetention(channel.ID); err != nil {
+		return err
+	}
+	slog.Info("removed a retention rule", slog.Any("channel.id", channel.ID), slog.Any("user.id", event.User().ID))
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("retention.removed", discord.ChannelMention(channel.ID))).
+		Build())
+}
+
+// describeRetention describes what the retention keeps.
+func describeRetention(tr i18n.Translator, retention purge.Retention) string {
+	var limits string
+	switch {
+	case retention.MaxCount > 0 && retention.MaxAge > 0:
+		limits = tr.T("retention.both", tr.T("retention.max_count", retention.MaxCount), tr.T("retention.max_age", retention.MaxAge))
+	case retention.MaxCount > 0:
+		limits = tr.T("retention.max_count", retention.MaxCount)
+	default:
+		limits = tr.T("retention.max_age", retention.MaxAge)
+	}
+	return tr.T("retention.description", discord.ChannelMention(retention.ChannelID), limits, retention.Every)
+}
+
+// RunRetention enforces due retention rules one after another until ctx is done.
+func (h *Handler) RunRetention(ctx context.Context, client bot.Client) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.enforceRetentions(ctx, client)
+		}
+	}
+}
+
+func (h *Handler) enforceRetentions(ctx context.Context, client bot.Client) {
+	retentions, err := h.store.Retentions()
+	if err != nil {
+		slog.Error("error while loading retention rules", tint.Err(err))
+		return
+	}
+	for _, retention := range retentions {
+		now := time.Now()
+		if !retention.Due(now) {
+			continue
+		}
+		record := purge.NewRecord(retention.GuildID, retention.ChannelID, retention.UserID, purge.ModeRetention)
+		runCtx, done := h.controller.StartRun(context.Background())
+		_, _ = h.recorded(h.index.Execute, record)(runCtx, client.Rest(), retention.Job(now), nil)
+		done()
+		if errors.Is(context.Cause(runCtx), purge.ErrInterrupted) {
+			// the rule is due again once the bot is back
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// the rule may have been changed or removed while it was enforced
+		current, err := h.store.Retention(retention.ChannelID)
+		if err != nil || current == nil {
+			continue
+		}
+		current.LastRun = now
+		if err := h.store.PutRetention(*current); err != nil {
+			record.Logger().Error("error while updating a retention rule", tint.Err(err))
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+const schedulerInterval = 30 * time.Second
+
+// parseTime parses either a duration from now like 2h or an absolute time in RFC 3339 or "2006-01-02 15:04" UTC format.
+func parseTime(text string, now time.Time) (time.Time, bool) {
+	if d, err := parseDuration(text); err == nil {
+		return now.Add(d), true
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", text, time.UTC); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseMessageID returns the message ID of a message link or a plain ID.
+func parseMessageID(text string) snowflake.ID {
+	ids := parseSnowflakes(text)
+	if len(ids) == 0 {
+		return 0
+	}
+	return ids[len(ids)-1]
+}
+
+func (h *Handler) HandleSchedule(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	now := time.Now()
+	at, ok := parseTime(data.String("at"), now)
+	if !ok || !at.After(now) {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("schedule.invalid_time")).
+			Build())
+	}
+	channelID := event.Channel().ID()
+	if channel, ok := data.OptChannel("channel"); ok {
+		channelID = channel.ID
+	}
+	schedule := purge.Schedule{
+		ID:        snowflake.New(now),
+		GuildID:   *event.GuildID(),
+		ChannelID: channelID,
+		UserID:    event.User().ID,
+		At:        at,
+		AuthorIDs: parseSnowflakes(data.String("authors")),
+	}
+	if text, ok := data.OptString("window"); ok {
+		if schedule.Window, ok = parseWindow(text); !ok {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("window.invalid")).
+				Build())
+		}
+	} else {
+		schedule.StartID = parseMessageID(data.String("start"))
+		schedule.EndID = parseMessageID(data.String("end"))
+		if schedule.StartID == 0 || schedule.EndID == 0 {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("schedule.missing_range")).
+				Build())
+		}
+		if at.Sub(min(schedule.StartID, schedule.EndID).Time()) > 14*24*time.Hour {
+			return event.CreateMessage(messageBuilder.
+				SetContent(tr.T("schedule.too_old")).
+				Build())
+		}
+	}
+	permissions, err := newPermissionResolver(event.Client().Rest(), event.ApplicationCommandInteraction, nil)
+	if err != nil {
+		return err
+	}
+	if schedule.MaxCount, err = h.authorizeTarget(event.ApplicationCommandInteraction, permissions, channelID, purge.ModeSchedule); err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("schedule.denied", discord.ChannelMention(channelID), denial(tr, err))).
+			Build())
+	}
+	if err := h.store.PutSchedule(schedule); err != nil {
+		return err
+	}
+	slog.Info("scheduled a purge", slog.Any("schedule.id", schedule.ID), slog.Any("channel.id", channelID), slog.Any("user.id", schedule.UserID), slog.Time("at", at))
+
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("schedule.scheduled", describeSchedule(tr, schedule), discord.FormattedTimestampMention(at.Unix(), discord.TimestampStyleRelative))).
+		AddActionRow(discord.NewDangerButton(tr.T("schedule.cancel_button"), "/purge/schedule/"+schedule.ID.String()+"/cancel")).
+		Build())
+}
+
+func (h *Handler) HandleScheduleCancel(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	schedule, err := h.store.Schedule(snowflake.MustParse(event.Vars["schedule-id"]))
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("schedule.gone")).
+			Build())
+	}
+	if schedule.UserID != event.User().ID && !h.canTakeOver(event.ComponentInteraction) {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("schedule.cancel_denied")).
+			Build())
+	}
+	if err := h.store.DeleteSchedule(schedule.ID); err != nil {
+		return err
+	}
+	slog.Info("canceled a scheduled purge", slog.Any("schedule.id", schedule.ID), slog.Any("user.id", event.User().ID))
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("schedule.canceled", describeSchedule(tr, *schedule))).
+		ClearContainerComponents().
+		Build())
+}
+
+// describeSchedule describes what the schedule purges.
+func describeSchedule(tr i18n.Translator, schedule purge.Schedule) string {
+	description := tr.T("schedule.range",
+		discord.MessageURL(schedule.GuildID, schedule.ChannelID, schedule.StartID),
+		discord.MessageURL(schedule.GuildID, schedule.ChannelID, schedule.EndID))
+	if schedule.Window > 0 {
+		description = tr.T("schedule.window", schedule.Window, discord.ChannelMention(schedule.ChannelID))
+	}
+	if len(schedule.AuthorIDs) > 0 {
+		description = tr.T("schedule.authors", description, mentionUsers(schedule.AuthorIDs))
+	}
+	return description
+}
+
+// RunScheduler runs due scheduled purges until ctx is done. Purges which became due while the bot was offline run right away.
+func (h *Handler) RunScheduler(ctx context.Context, client bot.Client) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		h.runDueSchedules(client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Handler) runDueSchedules(client bot.Client) {
+	schedules, err := h.store.Schedules()
+	if err != nil {
+		slog.Error("error while loading scheduled purges", tint.Err(err))
+		return
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		if schedule.At.After(now) {
+			continue
+		}
+		// remove the schedule before running it so it never runs twice
+		if err := h.store.DeleteSchedule(schedule.ID); err != nil {
+			slog.Error("error while removing a due scheduled purge", slog.Any("schedule.id", schedule.ID), tint.Err(err))
+			continue
+		}
+		ctx, done := h.controller.StartRun(context.Background())
+		go func() {
+			defer done()
+			h.runSchedule(ctx, client, schedule)
+		}()
+	}
+}
+
+func (h *Handler) runSchedule(ctx context.Context, client bot.Client, schedule purge.Schedule) {
+	record := purge.NewRecord(schedule.GuildID, schedule.ChannelID, schedule.UserID, purge.ModeSchedule)
+	record.Logger().Info("running a scheduled purge", slog.Any("schedule.id", schedule.ID))
+	job := schedule.Job(time.Now())
+	total, err := h.recorded(h.index.Execute, record)(ctx, client.Rest(), job, nil)
+	if errors.Is(context.Cause(ctx), purge.ErrInterrupted) {
+		h.reschedule(client, schedule, *record, job)
+		return
+	}
+	tr := h.guildTranslator(schedule.GuildID)
+	content := tr.T("schedule.finished", describeSchedule(tr, schedule), total)
+	if err != nil {
+		content = tr.T("schedule.error", describeSchedule(tr, schedule), total, err)
+	}
+	h.notify(client, schedule.UserID, content)
+}
+
+// reschedule stores the remainder of the interrupted scheduled purge as a schedule which is due right away, so it
+// resumes once the bot is back.
+func (h *Handler) reschedule(client bot.Client, schedule purge.Schedule, record purge.Record, job purge.Job) {
+	tr := h.guildTranslator(schedule.GuildID)
+	content := tr.T("schedule.interrupted", record.Count, describeSchedule(tr, schedule))
+	if checkpoint, ok := purge.NewCheckpoint(schedule.ID, record, job); ok {
+		remainder := schedule
+		remainder.At = checkpoint.InterruptedAt
+		remainder.Window = 0
+		remainder.StartID = checkpoint.StartID
+		remainder.EndID = checkpoint.EndID
+		remainder.MaxCount = checkpoint.MaxCount
+		if err := h.store.PutSchedule(remainder); err != nil {
+			record.Logger().Error("error while rescheduling an interrupted purge", slog.Any("schedule.id", schedule.ID), tint.Err(err))
+			content = tr.T("schedule.reschedule_error", record.Count, describeSchedule(tr, schedule))
+		}
+	}
+	h.notify(client, schedule.UserID, content)
+}
+
+// notify sends the content to the user in direct messages.
+func (h *Handler) notify(client bot.Client, userID snowflake.ID, content string) {
+	channel, err := client.Rest().CreateDMChannel(userID)
+	if err == nil {
+		_, err = client.Rest().CreateMessage(channel.ID(), discord.NewMessageCreateBuilder().
+			SetContent(content).
+			Build())
+	}
+	if err != nil {
+		slog.Error("error while notifying a user", slog.Any("user.id", userID), tint.Err(err))
+	}
+}
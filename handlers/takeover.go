@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"advanced-purge/i18n"
+	"advanced-purge/purge"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+// canTakeOver reports whether the interaction's member may take over or cancel purge setups of other users.
+func (h *Handler) canTakeOver(interaction discord.Interaction) bool {
+	member := interaction.Member()
+	if interaction.GuildID() == nil || member == nil {
+		return false
+	}
+	return member.Permissions.Has(discord.PermissionAdministrator) || h.policies.Senior(*interaction.GuildID(), member.RoleIDs)
+}
+
+// offerTakeOver lists the other idle purge setups in the channel with buttons to take them over or cancel them.
+// Taking over a setup discards the own setup if it has not chosen a mode yet.
+func (h *Handler) offerTakeOver(event *handler.CommandEvent, own *purge.Purge) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	content := tr.T("takeover.offer")
+	for _, other := range h.controller.Purges(own.ChannelID) {
+		if other == own || h.controller.Running(other) || h.controller.Pending(other) {
+			continue
+		}
+		if len(messageBuilder.Components) == 5 {
+			break
+		}
+		n := strconv.Itoa(len(messageBuilder.Components) + 1)
+		content += "\n" + n + ". " + discord.UserMention(other.UserID)
+		messageBuilder.AddActionRow(
+			discord.NewPrimaryButton(tr.T("takeover.take_over_button", n), "/purge/owner/"+other.UserID.String()+"/take-over"),
+			discord.NewDangerButton(tr.T("takeover.force_cancel_button", n), "/purge/owner/"+other.UserID.String()+"/force-cancel"))
+	}
+	if len(messageBuilder.Components) == 0 {
+		return nil
+	}
+	_, err := event.CreateFollowupMessage(messageBuilder.
+		SetContent(content).
+		Build())
+	return err
+}
+
+func (h *Handler) MiddlewareTakeOver() handler.Middleware {
+	return func(next handler.Handler) handler.Handler {
+		return func(event *handler.InteractionEvent) error {
+			tr := h.translator(event)
+			messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+			if !h.canTakeOver(event.Interaction) {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("takeover.denied")).
+					Build())
+			}
+			ownerID, err := snowflake.Parse(event.Vars["user-id"])
+			if err != nil {
+				return err
+			}
+			purge := h.controller.Purge(event.Channel().ID(), ownerID)
+			if purge == nil {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("takeover.gone")).
+					Build())
+			}
+			if h.controller.Running(purge) {
+				return event.CreateMessage(messageBuilder.
+					SetContent(tr.T("takeover.running")).
+					Build())
+			}
+			return next(event)
+		}
+	}
+}
+
+func (h *Handler) HandleTakeOver(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	channelID := event.Channel().ID()
+	p := h.controller.Purge(channelID, snowflake.MustParse(event.Vars["user-id"]))
+	previousID, discarded, err := h.controller.TakeOver(p, event.User().ID)
+	if err != nil {
+		content := tr.T("takeover.exists")
+		switch {
+		case errors.Is(err, purge.ErrSetupGone):
+			content = tr.T("takeover.gone")
+		case errors.Is(err, purge.ErrSetupBusy):
+			content = tr.T("takeover.busy")
+		}
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(content).
+			Build())
+	}
+	if discarded != nil && discarded.PromptID != 0 {
+		if _, err := event.Client().Rest().UpdateMessage(channelID, discarded.PromptID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("takeover.discarded")).
+			ClearContainerComponents().
+			Build()); err != nil {
+			discarded.Logger().Error("error while closing a discarded purge setup", tint.Err(err))
+		}
+	}
+	p.Logger().Info("purge setup taken over", slog.Any("previous.user.id", previousID))
+
+	return event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetContent(tr.T("takeover.done", event.User().ID, previousID)).
+		AddContainerComponents(setupActionRow(tr, p)).
+		Build())
+}
+
+func (h *Handler) HandleForceCancel(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	channelID := event.Channel().ID()
+	ownerID := snowflake.MustParse(event.Vars["user-id"])
+	p, ok := h.controller.ForceCancel(channelID, ownerID)
+	if !ok {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(tr.T("takeover.cannot_cancel")).
+			Build())
+	}
+	logger := p.Logger()
+	logger.Info("purge setup force canceled", slog.Any("canceler.id", event.User().ID))
+
+	if p.Pending() && p.Approval.RequestID != 0 {
+		if _, err := event.Client().Rest().UpdateMessage(p.Approval.RequestChannelID, p.Approval.RequestID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("takeover.canceled_request", event.User().ID)).
+			ClearContainerComponents().
+			Build()); err != nil {
+			logger.Error("error while withdrawing an approval request", tint.Err(err))
+		}
+	}
+	if p.PromptID != 0 {
+		if _, err := event.Client().Rest().UpdateMessage(channelID, p.PromptID, discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("takeover.canceled_prompt", event.User().ID)).
+			ClearContainerComponents().
+			Build()); err != nil {
+			logger.Error("error while marking a purge setup as canceled", tint.Err(err))
+		}
+	}
+
+	return event.CreateMessage(discord.NewMessageCreateBuilder().
+		SetContent(tr.T("takeover.canceled", event.User().ID, ownerID)).
+		Build())
+}
+
+// setupActionRow returns the buttons to continue a purge setup from its current state.
+func setupActionRow(tr i18n.Translator, p *purge.Purge) discord.ActionRowComponent {
+	switch {
+	case p.Mode == "" && purge.IsThread(p.ChannelType):
+		return discord.NewActionRow(
+			discord.NewPrimaryButton(tr.T("button.simple"), "/purge/simple"),
+			discord.NewPrimaryButton(tr.T("button.advanced"), "/purge/advanced"),
+			discord.NewSecondaryButton(tr.T("button.reactions"), "/purge/reactions"),
+			discord.NewDangerButton(tr.T("button.thread"), "/purge/thread"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel"))
+	case p.Mode == "":
+		return discord.NewActionRow(
+			discord.NewPrimaryButton(tr.T("button.simple"), "/purge/simple"),
+			discord.NewPrimaryButton(tr.T("button.advanced"), "/purge/advanced"),
+			discord.NewSecondaryButton(tr.T("button.reactions"), "/purge/reactions"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel"))
+	case p.StartID != 0 && p.EndID != 0:
+		return discord.NewActionRow(
+			discord.NewPrimaryButton(tr.T("button.run"), "/purge/run"),
+			discord.NewSecondaryButton(tr.T("button.collect"), "/purge/collect"),
+			discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel"))
+	default:
+		return discord.NewActionRow(discord.NewDangerButton(tr.T("button.cancel"), "/purge/cancel"))
+	}
+}
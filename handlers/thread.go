@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"advanced-purge/purge"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/lmittmann/tint"
+)
+
+func (h *Handler) HandleThread(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	if !purge.IsThread(event.Channel().Type()) {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("thread.not_thread")).
+			Build())
+	}
+	if _, err := h.authorize(event.ComponentInteraction, purge.ModeThread, 0); err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("thread.denied", denial(tr, err))).
+			Build())
+	}
+	return event.CreateMessage(messageBuilder.
+		SetContent(tr.T("thread.confirm")).
+		AddActionRow(
+			discord.NewDangerButton(tr.T("thread.confirm_button"), "/purge/thread/confirm"),
+			discord.NewSecondaryButton(tr.T("button.cancel"), "/purge/cancel")).
+		Build())
+}
+
+func (h *Handler) HandleThreadConfirm(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	channelID := event.Channel().ID()
+	if _, err := h.authorize(event.ComponentInteraction, purge.ModeThread, 0); err != nil {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("thread.denied", denial(tr, err))).
+			Build())
+	}
+	h.controller.RemovePurge(channelID, event.User().ID)
+	if err := event.DeferUpdateMessage(); err != nil {
+		return err
+	}
+	record := purge.NewRecord(*event.GuildID(), channelID, event.User().ID, purge.ModeThread)
+	if _, err := h.recorded(deleteThread, record)(context.Background(), event.Client().Rest(), purge.Job{ChannelID: channelID}, nil); err != nil {
+		_, err := event.CreateFollowupMessage(discord.NewMessageCreateBuilder().
+			SetContent(tr.T("thread.error", err)).
+			Build())
+		return err
+	}
+	return nil
+}
+
+// deleteThread deletes the job's channel, which is a thread, and counts it as 1 deleted thread.
+func deleteThread(ctx context.Context, client rest.Rest, job purge.Job, _ purge.ProgressFunc) (int, error) {
+	if err := client.DeleteChannel(job.ChannelID, rest.WithCtx(ctx)); err != nil {
+		return 0, err
+	}
+	if job.Deleted != nil {
+		job.Deleted([]snowflake.ID{job.ChannelID})
+	}
+	return 1, nil
+}
+
+func (h *Handler) HandleForum(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder().SetEphemeral(true)
+	forum := data.Channel("forum")
+	if !purge.IsForum(forum.Type) {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("forum.not_forum")).
+			Build())
+	}
+	window, ok := parseWindow(data.String("window"))
+	if !ok {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("forum.invalid_window")).
+			Build())
+	}
+	if _, err := h.authorizeChannel(event.ApplicationCommandInteraction, forum.ID, purge.ModeForum, 0); err != nil {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("forum.denied", discord.ChannelMention(forum.ID), denial(tr, err))).
+			Build())
+	}
+	if err := event.DeferCreateMessage(true); err != nil {
+		return err
+	}
+
+	before := time.Now()
+	after := before.Add(-window)
+	threads, err := forumThreads(event.Client().Rest(), *event.GuildID(), forum.ID, after, before)
+	if err != nil {
+		return err
+	}
+	if len(threads) == 0 {
+		_, err := event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+			SetContent(tr.T("forum.none", discord.ChannelMention(forum.ID), window)).
+			Build())
+		return err
+	}
+	_, err = event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("forum.confirm", len(threads), discord.ChannelMention(forum.ID), window)).
+		AddActionRow(
+			discord.NewDangerButton(tr.T("forum.confirm_button"), "/purge/forum/"+forum.ID.String()+"/"+snowflake.New(after).String()+"/"+snowflake.New(before).String()+"/confirm"),
+			discord.NewSecondaryButton(tr.T("button.cancel"), "/purge/forum/cancel")).
+		Build())
+	return err
+}
+
+// HandleForumConfirm deletes the threads of the forum created within the time window which has been confirmed.
+func (h *Handler) HandleForumConfirm(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	tr := h.translator(event)
+	forumID := snowflake.MustParse(event.Vars["forum-id"])
+	after := snowflake.MustParse(event.Vars["after"]).Time()
+	before := snowflake.MustParse(event.Vars["before"]).Time()
+	if _, err := h.authorizeChannel(event.ComponentInteraction, forumID, purge.ModeForum, 0); err != nil {
+		return event.CreateMessage(discord.NewMessageCreateBuilder().
+			SetEphemeral(true).
+			SetContent(tr.T("forum.denied", discord.ChannelMention(forumID), denial(tr, err))).
+			Build())
+	}
+	if err := event.DeferUpdateMessage(); err != nil {
+		return err
+	}
+	client := event.Client().Rest()
+	threads, err := forumThreads(client, *event.GuildID(), forumID, after, before)
+	if err != nil {
+		return err
+	}
+	if _, err := event.UpdateInteractionResponse(discord.NewMessageUpdateBuilder().
+		SetContent(tr.T("forum.running", len(threads), discord.ChannelMention(forumID))).
+		ClearContainerComponents().
+		Build()); err != nil {
+		return err
+	}
+
+	record := purge.NewRecord(*event.GuildID(), forumID, event.User().ID, purge.ModeForum)
+	logger := record.Logger()
+	logger.Info("starting a forum purge", slog.Int("threads", len(threads)), slog.Duration("window", before.Sub(after)))
+	ctx, done := h.controller.StartRun(context.Background())
+	go func() {
+		defer done()
+		var failed []string
+		deleteThreads := func(ctx context.Context, client rest.Rest, job purge.Job, _ purge.ProgressFunc) (int, error) {
+			var deleted int
+			for _, thread := range threads {
+				if ctx.Err() != nil {
+					return deleted, context.Cause(ctx)
+				}
+				if err := client.DeleteChannel(thread.ID(), rest.WithCtx(ctx)); err != nil {
+					if ctx.Err() != nil {
+						return deleted, context.Cause(ctx)
+					}
+					logger.Error("error while deleting a forum thread", slog.Any("thread.id", thread.ID()), tint.Err(err))
+					failed = append(failed, fmt.Sprintf("**%s**: **%s**", thread.Name(), err))
+					continue
+				}
+				job.Deleted([]snowflake.ID{thread.ID()})
+				deleted++
+			}
+			if len(failed) > 0 {
+				return deleted, fmt.Errorf("failed to delete %d threads", len(failed))
+			}
+			return deleted, nil
+		}
+		deleted, _ := h.recorded(deleteThreads, record)(ctx, client, purge.Job{ChannelID: forumID}, nil)
+		report := tr.T("forum.done", deleted, len(threads), discord.ChannelMention(forumID))
+		if errors.Is(context.Cause(ctx), purge.ErrInterrupted) {
+			report = tr.T("forum.interrupted", deleted, len(threads), discord.ChannelMention(forumID))
+		}
+		if len(failed) > 0 {
+			report = joinLines(report+"\n"+tr.T("forum.errors"), failed)
+		}
+		if _, err := event.CreateFollowupMessage(discord.NewMessageCreateBuilder().
+			SetContent(report).
+			Build()); err != nil {
+			logger.Error("error while responding with a forum purge report", tint.Err(err))
+		}
+	}()
+	return nil
+}
+
+func (h *Handler) HandleForumCancel(_ discord.ButtonInteractionData, event *handler.ComponentEvent) error {
+	return event.UpdateMessage(discord.NewMessageUpdateBuilder().
+		SetContent(h.translator(event).T("forum.canceled")).
+		ClearContainerComponents().
+		Build())
+}
+
+// forumThreads returns the active and archived threads of the forum created between after and before.
+func forumThreads(client rest.Rest, guildID snowflake.ID, forumID snowflake.ID, after time.Time, before time.Time) ([]discord.GuildThread, error) {
+	created := func(thread discord.GuildThread) bool {
+		return thread.CreatedAt().After(after) && !thread.CreatedAt().After(before)
+	}
+	var threads []discord.GuildThread
+	active, err := client.GetActiveGuildThreads(guildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, thread := range active.Threads {
+		if parentID := thread.ParentID(); parentID != nil && *parentID == forumID && created(thread) {
+			threads = append(threads, thread)
+		}
+	}
+	// archived threads are sorted by their archive time, threads archived before after were also created before it
+	archivedBefore := time.Now()
+	for {
+		archived, err := client.GetPublicArchivedThreads(forumID, archivedBefore, 100)
+		if err != nil {
+			return nil, err
+		}
+		for _, thread := range archived.Threads {
+			if created(thread) {
+				threads = append(threads, thread)
+			}
+			archivedBefore = thread.ThreadMetadata.ArchiveTimestamp
+		}
+		if !archived.HasMore || len(archived.Threads) == 0 || archivedBefore.Before(after) {
+			break
+		}
+	}
+	return threads, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"advanced-purge/purge"
+	"log/slog"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/handler"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func (h *Handler) HandleUser(data discord.SlashCommandInteractionData, event *handler.CommandEvent) error {
+	tr := h.translator(event)
+	messageBuilder := discord.NewMessageCreateBuilder()
+	since, ok := parseWindow(data.String("since"))
+	if !ok {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("user.invalid_since")).
+			Build())
+	}
+	guildID := *event.GuildID()
+	client := event.Client().Rest()
+	channels, err := client.GetGuildChannels(guildID)
+	if err != nil {
+		return err
+	}
+	threads, err := client.GetActiveGuildThreads(guildID)
+	if err != nil {
+		return err
+	}
+
+	for _, thread := range threads.Threads {
+		channels = append(channels, thread)
+	}
+	permissions, err := newPermissionResolver(client, event.ApplicationCommandInteraction, channels)
+	if err != nil {
+		return err
+	}
+
+	member := data.User("member")
+	now := time.Now()
+	job := purge.Job{
+		StartID:   snowflake.New(now),
+		EndID:     snowflake.New(now.Add(-since)),
+		AuthorIDs: []snowflake.ID{member.ID},
+	}
+	var (
+		jobs    []purge.Job
+		skipped int
+	)
+	for _, channel := range channels {
+		if !purge.SupportsMessages(channel.Type()) {
+			continue
+		}
+		maxCount, err := h.authorizeTarget(event.ApplicationCommandInteraction, permissions, channel.ID(), purge.ModeUser)
+		if err != nil {
+			skipped++
+			continue
+		}
+		channelJob := job
+		channelJob.ChannelID = channel.ID()
+		channelJob.MaxCount = maxCount
+		jobs = append(jobs, channelJob)
+	}
+	if len(jobs) == 0 {
+		return event.CreateMessage(messageBuilder.
+			SetContent(tr.T("user.denied")).
+			Build())
+	}
+	if skipped > 0 {
+		slog.Info("skipping channels denied by permissions or policies for a user purge", slog.Any("guild.id", guildID), slog.Int("channels", skipped))
+	}
+	record := purge.NewRecord(guildID, 0, event.User().ID, purge.ModeUser)
+	record.Logger().Info("starting a user purge", slog.Any("member.id", member.ID), slog.Duration("since", since))
+	return h.purgeChannels(event, tr.T("user.name", member.Mention()), record, nil, jobs)
+}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/gateway"
+	"github.com/disgoorg/disgo/rest"
+)
+
+// checkTimeout bounds the time a single check may take.
+const checkTimeout = 5 * time.Second
+
+// Check reports an error if the checked component is unhealthy.
+type Check func(ctx context.Context) error
+
+// Handler runs all checks and responds with 200 if all pass or with 503 listing the failed checks.
+func Handler(checks map[string]Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		var (
+			failures []string
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+		)
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := check(ctx); err != nil {
+					mu.Lock()
+					failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if len(failures) > 0 {
+			sort.Strings(failures)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprintln(w, strings.Join(failures, "\n"))
+			return
+		}
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+}
+
+// Gateway checks that the client's gateway has received its ready event.
+func Gateway(client bot.Client) Check {
+	return func(context.Context) error {
+		if !client.HasGateway() {
+			return errors.New("no gateway")
+		}
+		if status := client.Gateway().Status(); status != gateway.StatusReady {
+			return fmt.Errorf("gateway is %s", status)
+		}
+		return nil
+	}
+}
+
+// Rest checks that Discord's REST API is reachable, results are reused for interval.
+func Rest(client bot.Client, interval time.Duration) Check {
+	var (
+		mu        sync.Mutex
+		checkedAt time.Time
+		lastErr   error
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(checkedAt) < interval {
+			return lastErr
+		}
+		_, lastErr = client.Rest().GetGateway(rest.WithCtx(ctx))
+		checkedAt = time.Now()
+		return lastErr
+	}
+}
+
+// Shutdown fails once the shutdown has begun so no new work is routed to the bot.
+type Shutdown struct {
+	down atomic.Bool
+}
+
+// Begin marks the shutdown as begun.
+func (s *Shutdown) Begin() {
+	s.down.Store(true)
+}
+
+func (s *Shutdown) Check(context.Context) error {
+	if s.down.Load() {
+		return errors.New("shutting down")
+	}
+	return nil
+}
+
+// Tracker tracks the handlers in flight to detect deadlocked ones.
+type Tracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	started map[uint64]time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		started: make(map[uint64]time.Time),
+	}
+}
+
+// Start marks a handler as started and returns the func marking it as done.
+func (t *Tracker) Start() func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	id := t.nextID
+	t.started[id] = time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.started, id)
+	}
+}
+
+// Stalled fails if a handler has been running for longer than timeout.
+func (t *Tracker) Stalled(timeout time.Duration) Check {
+	return func(context.Context) error {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for _, started := range t.started {
+			if running := time.Since(started); running > timeout {
+				return fmt.Errorf("a handler has been running for %s", running.Round(time.Second))
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+)
+
+// Fallback is the locale of the catalog every other catalog falls back to.
+const Fallback = discord.LocaleEnglishUS
+
+//go:embed catalogs/*.json
+var files embed.FS
+
+// CommandText is the localized name and description of a command, subcommand or option.
+type CommandText struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Catalog holds the messages of a locale by key and the texts of the commands by their path like "purge raid window".
+// The commands are named and described in the command definitions in the fallback locale.
+type Catalog struct {
+	Messages map[string]string      `json:"messages"`
+	Commands map[string]CommandText `json:"commands"`
+}
+
+// Catalogs maps locales to their catalog.
+type Catalogs map[discord.Locale]Catalog
+
+// Load parses the embedded catalogs, which are named after their locale, and checks that every message of a catalog
+// exists in the fallback catalog with the same amount of arguments.
+func Load() (Catalogs, error) {
+	entries, err := files.ReadDir("catalogs")
+	if err != nil {
+		return nil, err
+	}
+	catalogs := make(Catalogs, len(entries))
+	for _, entry := range entries {
+		locale := discord.Locale(strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+		if _, ok := discord.Locales[locale]; !ok {
+			return nil, fmt.Errorf("catalog %s is not named after a discord locale", entry.Name())
+		}
+		data, err := files.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse catalog %s: %w", entry.Name(), err)
+		}
+		catalogs[locale] = catalog
+	}
+	fallback, ok := catalogs[Fallback]
+	if !ok {
+		return nil, fmt.Errorf("missing the %s catalog", Fallback)
+	}
+	for locale, catalog := range catalogs {
+		for key, message := range catalog.Messages {
+			original, ok := fallback.Messages[key]
+			if !ok {
+				return nil, fmt.Errorf("catalog %s: unknown message %q", locale, key)
+			}
+			if countVerbs(message) != countVerbs(original) {
+				return nil, fmt.Errorf("catalog %s: message %q does not take the arguments of the %s message", locale, key, Fallback)
+			}
+		}
+	}
+	return catalogs, nil
+}
+
+// countVerbs returns the amount of formatting verbs in the message.
+func countVerbs(message string) int {
+	return strings.Count(message, "%") - 2*strings.Count(message, "%%")
+}
+
+// Has reports whether there is a catalog for the locale.
+func (c Catalogs) Has(locale discord.Locale) bool {
+	_, ok := c[locale]
+	return ok
+}
+
+// Translator returns the translator of the catalog for the locale. Locales without their own catalog use the
+// catalog of the same language, e.g. en-GB uses en-US, and the fallback catalog otherwise.
+func (c Catalogs) Translator(locale discord.Locale) Translator {
+	translator := Translator{
+		fallback: c[Fallback].Messages,
+	}
+	if catalog, ok := c[locale]; ok {
+		translator.messages = catalog.Messages
+		return translator
+	}
+	language, _, _ := strings.Cut(string(locale), "-")
+	for other, catalog := range c {
+		if otherLanguage, _, _ := strings.Cut(string(other), "-"); otherLanguage == language {
+			translator.messages = catalog.Messages
+			break
+		}
+	}
+	return translator
+}
+
+// Translator formats the messages of a catalog.
+type Translator struct {
+	messages map[string]string
+	fallback map[string]string
+}
+
+// T formats the message with the key like fmt.Sprintf. Messages missing in the catalog are taken from the fallback
+// catalog, unknown keys are returned as they are.
+func (t Translator) T(key string, args ...any) string {
+	message, ok := t.messages[key]
+	if !ok {
+		if message, ok = t.fallback[key]; !ok {
+			message = key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// LocalizeCommands returns copies of the commands with the localized names and descriptions of the catalogs.
+func (c Catalogs) LocalizeCommands(commands []discord.ApplicationCommandCreate) []discord.ApplicationCommandCreate {
+	localized := make([]discord.ApplicationCommandCreate, len(commands))
+	for i, command := range commands {
+		switch command := command.(type) {
+		case discord.SlashCommandCreate:
+			command.NameLocalizations, command.DescriptionLocalizations = c.commandTexts(command.Name)
+			command.Options = c.localizeOptions(command.Name, command.Options)
+			localized[i] = command
+		case discord.MessageCommandCreate:
+			command.NameLocalizations, _ = c.commandTexts(command.Name)
+			localized[i] = command
+		case discord.UserCommandCreate:
+			command.NameLocalizations, _ = c.commandTexts(command.Name)
+			localized[i] = command
+		default:
+			localized[i] = command
+		}
+	}
+	return localized
+}
+
+// localizeOptions returns copies of the options of the command with the path with their localized names and descriptions.
+func (c Catalogs) localizeOptions(commandPath string, options []discord.ApplicationCommandOption) []discord.ApplicationCommandOption {
+	if len(options) == 0 {
+		return options
+	}
+	localized := make([]discord.ApplicationCommandOption, len(options))
+	for i, option := range options {
+		optionPath := commandPath + " " + option.OptionName()
+		names, descriptions := c.commandTexts(optionPath)
+		switch option := option.(type) {
+		case discord.ApplicationCommandOptionSubCommand:
+			option.NameLocalizations, option.DescriptionLocalizations = names, descriptions
+			option.Options = c.localizeOptions(optionPath, option.Options)
+			localized[i] = option
+		case discord.ApplicationCommandOptionString:
+			option.NameLocalizations, option.DescriptionLocalizations = names, descriptions
+			localized[i] = option
+		case discord.ApplicationCommandOptionInt:
+			option.NameLocalizations, option.DescriptionLocalizations = names, descriptions
+			localized[i] = option
+		case discord.ApplicationCommandOptionUser:
+			option.NameLocalizations, option.DescriptionLocalizations = names, descriptions
+			localized[i] = option
+		case discord.ApplicationCommandOptionChannel:
+			option.NameLocalizations, option.DescriptionLocalizations = names, descriptions
+			localized[i] = option
+		default:
+			localized[i] = option
+		}
+	}
+	return localized
+}
+
+// commandTexts returns the localized names and descriptions of the command, subcommand or option with the path.
+func (c Catalogs) commandTexts(commandPath string) (map[discord.Locale]string, map[discord.Locale]string) {
+	var names, descriptions map[discord.Locale]string
+	for locale, catalog := range c {
+		if locale == Fallback {
+			continue
+		}
+		text := catalog.Commands[commandPath]
+		if text.Name != "" {
+			if names == nil {
+				names = make(map[discord.Locale]string)
+			}
+			names[locale] = text.Name
+		}
+		if text.Description != "" {
+			if descriptions == nil {
+				descriptions = make(map[discord.Locale]string)
+			}
+			descriptions[locale] = text.Description
+		}
+	}
+	return names, descriptions
+}
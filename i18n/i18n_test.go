@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgo/discord"
+)
+
+func TestLoad(t *testing.T) {
+	catalogs, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, locale := range []discord.Locale{Fallback, discord.LocaleGerman, discord.LocaleFrench} {
+		if !catalogs.Has(locale) {
+			t.Errorf("missing the %s catalog", locale)
+		}
+	}
+}
+
+func TestTranslator(t *testing.T) {
+	catalogs := Catalogs{
+		Fallback:                {Messages: map[string]string{"greeting": "Hello %s", "only": "fallback"}},
+		discord.LocaleGerman:    {Messages: map[string]string{"greeting": "Hallo %s"}},
+		discord.LocaleEnglishGB: {Messages: map[string]string{"greeting": "Hiya %s"}},
+	}
+	tests := []struct {
+		locale discord.Locale
+		key    string
+		want   string
+	}{
+		{discord.LocaleGerman, "greeting", "Hallo Ada"},
+		{discord.LocaleGerman, "only", "fallback"},
+		{discord.LocaleGerman, "unknown", "unknown"},
+		{discord.LocaleJapanese, "greeting", "Hello Ada"},
+		{discord.LocaleEnglishGB, "greeting", "Hiya Ada"},
+	}
+	for _, test := range tests {
+		var args []any
+		if test.key == "greeting" {
+			args = append(args, "Ada")
+		}
+		if got := catalogs.Translator(test.locale).T(test.key, args...); got != test.want {
+			t.Errorf("Translator(%s).T(%q) = %q, want %q", test.locale, test.key, got, test.want)
+		}
+	}
+}
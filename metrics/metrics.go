@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "advanced_purge"
+
+var (
+	PurgesStarted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "purges_started_total",
+		Help:      "Purge runs started per channel, by mode.",
+	}, []string{"mode"})
+	PurgesCompleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "purges_completed_total",
+		Help:      "Purge runs completed per channel, by mode.",
+	}, []string{"mode"})
+	PurgesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "purges_failed_total",
+		Help:      "Purge runs failed per channel, by mode.",
+	}, []string{"mode"})
+	MessagesDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_deleted_total",
+		Help:      "Messages deleted by purges.",
+	})
+	BulkDeleteLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "bulk_delete_duration_seconds",
+		Help:      "Latency of bulk delete requests including rate limit waits.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+	RateLimited = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rest_rate_limited_total",
+		Help:      "REST responses with status 429 Too Many Requests.",
+	})
+	HandlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handler_errors_total",
+		Help:      "Errors returned by interaction handlers, by route.",
+	}, []string{"route"})
+)
+
+// ObserveSessions exports the amount of active purge sessions reported by sessions.
+func ObserveSessions(sessions func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Purge setups currently held by the controller.",
+	}, func() float64 {
+		return float64(sessions())
+	})
+}
+
+// ObserveBulkDelete records the latency of a bulk delete started at started.
+func ObserveBulkDelete(started time.Time) {
+	BulkDeleteLatency.Observe(time.Since(started).Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// transport counts the rate limited responses of the wrapped round tripper.
+type transport struct {
+	next http.RoundTripper
+}
+
+// Transport wraps next to count rate limited REST responses.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return transport{next: next}
+}
+
+func (t transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(request)
+	if err == nil && response.StatusCode == http.StatusTooManyRequests {
+		RateLimited.Inc()
+	}
+	return response, err
+}
@@ -0,0 +1,73 @@
+package purge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// ApprovalConfig requires a second moderator holding one of RoleIDs to approve purges of more than Threshold
+// messages or any purge in ProtectedChannels. A Threshold of 0 disables the size check. Requests are posted to the
+// mod-log channel and expire after Timeout.
+type ApprovalConfig struct {
+	ModLogChannelID   snowflake.ID   `json:"mod_log_channel_id"`
+	Threshold         int            `json:"threshold"`
+	ProtectedChannels []snowflake.ID `json:"protected_channels"`
+	RoleIDs           []snowflake.ID `json:"role_ids"`
+	Timeout           Duration       `json:"timeout"`
+}
+
+// ApprovalConfigs maps guild IDs to their approval configuration. Purges in guilds without one never need approval.
+type ApprovalConfigs map[snowflake.ID]ApprovalConfig
+
+func LoadApprovalConfigs(path string) (ApprovalConfigs, error) {
+	configs := make(ApprovalConfigs)
+	if path == "" {
+		return configs, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse approval configs: %w", err)
+	}
+	return configs, nil
+}
+
+// Protected reports whether every purge in the channel needs approval.
+func (c ApprovalConfig) Protected(channelID snowflake.ID) bool {
+	return slices.Contains(c.ProtectedChannels, channelID)
+}
+
+// Required reports whether purging count messages in the channel needs approval.
+func (c ApprovalConfig) Required(channelID snowflake.ID, count int) bool {
+	return c.Protected(channelID) || (c.Threshold > 0 && count > c.Threshold)
+}
+
+// Eligible reports whether a member holding the roles may approve purges.
+func (c ApprovalConfig) Eligible(roleIDs []snowflake.ID) bool {
+	return slices.ContainsFunc(c.RoleIDs, func(roleID snowflake.ID) bool {
+		return slices.Contains(roleIDs, roleID)
+	})
+}
+
+// Approval is the pending or granted approval of a purge by a second moderator.
+type Approval struct {
+	ChannelID        snowflake.ID
+	UserID           snowflake.ID
+	RequestChannelID snowflake.ID
+	RequestID        snowflake.ID
+	Count            int
+	ExpiresAt        time.Time
+	ApproverID       snowflake.ID
+}
+
+// Pending reports whether the purge waits for an approval.
+func (p *Purge) Pending() bool {
+	return p.Approval != nil && p.Approval.ApproverID == 0
+}
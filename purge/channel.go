@@ -0,0 +1,42 @@
+package purge
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/disgoorg/disgo/discord"
+)
+
+var (
+	ErrUnsupportedChannel = errors.New("messages cannot be purged in this type of channel")
+
+	// messageChannelTypes are the types of channels whose messages can be purged, including the text chats of voice channels.
+	messageChannelTypes = []discord.ChannelType{
+		discord.ChannelTypeGuildText,
+		discord.ChannelTypeGuildNews,
+		discord.ChannelTypeGuildVoice,
+		discord.ChannelTypeGuildStageVoice,
+		discord.ChannelTypeGuildNewsThread,
+		discord.ChannelTypeGuildPublicThread,
+		discord.ChannelTypeGuildPrivateThread,
+	}
+	threadChannelTypes = []discord.ChannelType{
+		discord.ChannelTypeGuildNewsThread,
+		discord.ChannelTypeGuildPublicThread,
+		discord.ChannelTypeGuildPrivateThread,
+	}
+)
+
+func SupportsMessages(channelType discord.ChannelType) bool {
+	return slices.Contains(messageChannelTypes, channelType)
+}
+
+// IsThread reports whether the channel type is a thread, which includes forum and media posts.
+func IsThread(channelType discord.ChannelType) bool {
+	return slices.Contains(threadChannelTypes, channelType)
+}
+
+// IsForum reports whether the channel type only contains threads.
+func IsForum(channelType discord.ChannelType) bool {
+	return channelType == discord.ChannelTypeGuildForum || channelType == discord.ChannelTypeGuildMedia
+}
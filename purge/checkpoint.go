@@ -0,0 +1,88 @@
+package purge
+
+import (
+	"errors"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// ErrInterrupted is the cause of runs cancelled because the bot shuts down.
+var ErrInterrupted = errors.New("interrupted by restart")
+
+// Checkpoint is the remainder of a purge in a channel which has been interrupted by a restart. The checkpoints of
+// a purge interrupted in multiple channels share their group ID.
+type Checkpoint struct {
+	ID            snowflake.ID   `json:"id"`
+	CorrelationID snowflake.ID   `json:"correlation_id"`
+	GroupID       snowflake.ID   `json:"group_id"`
+	GuildID       snowflake.ID   `json:"guild_id"`
+	ChannelID     snowflake.ID   `json:"channel_id"`
+	UserID        snowflake.ID   `json:"user_id"`
+	Mode          Mode           `json:"mode"`
+	StartID       snowflake.ID   `json:"start_id"`
+	EndID         snowflake.ID   `json:"end_id"`
+	Forwards      bool           `json:"forwards,omitempty"`
+	BulkLimit     int            `json:"bulk_limit,omitempty"`
+	MaxCount      int            `json:"max_count,omitempty"`
+	AuthorIDs     []snowflake.ID `json:"author_ids,omitempty"`
+	Reason        string         `json:"reason,omitempty"`
+	Reactions     ReactionFilter `json:"reactions"`
+	Exclusions    Exclusions     `json:"exclusions"`
+	Deleted       int            `json:"deleted"`
+	InterruptedAt time.Time      `json:"interrupted_at"`
+}
+
+// NewCheckpoint returns the checkpoint continuing the job of the record after its last deleted message with the job's
+// exclusions. It returns false if the job has reached its max count.
+func NewCheckpoint(groupID snowflake.ID, record Record, job Job) (Checkpoint, bool) {
+	startID := job.StartID
+	for _, messageID := range record.MessageIDs {
+		if job.Forwards {
+			startID = max(startID, messageID)
+		} else {
+			startID = min(startID, messageID)
+		}
+	}
+	maxCount := job.MaxCount
+	if maxCount > 0 {
+		if maxCount -= record.Count; maxCount <= 0 {
+			return Checkpoint{}, false
+		}
+	}
+	return Checkpoint{
+		ID:            record.ID,
+		CorrelationID: record.CorrelationID,
+		GroupID:       groupID,
+		GuildID:       record.GuildID,
+		ChannelID:     job.ChannelID,
+		UserID:        record.UserID,
+		Mode:          record.Mode,
+		StartID:       startID,
+		EndID:         job.EndID,
+		Forwards:      job.Forwards,
+		BulkLimit:     job.BulkLimit,
+		MaxCount:      maxCount,
+		AuthorIDs:     job.AuthorIDs,
+		Reason:        job.Reason,
+		Exclusions:    job.Exclusions,
+		Deleted:       record.Count,
+		InterruptedAt: time.Now(),
+	}, true
+}
+
+// Job returns the job resuming the purge, roles resolves the roles of members for role exclusions.
+func (c Checkpoint) Job(roles RolesFunc) Job {
+	return Job{
+		ChannelID:  c.ChannelID,
+		StartID:    c.StartID,
+		EndID:      c.EndID,
+		Forwards:   c.Forwards,
+		BulkLimit:  c.BulkLimit,
+		MaxCount:   c.MaxCount,
+		AuthorIDs:  c.AuthorIDs,
+		Reason:     c.Reason,
+		Keep:       c.Exclusions.Exclusion(roles),
+		Exclusions: c.Exclusions,
+	}
+}
@@ -0,0 +1,58 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// interruptAfter returns the record of running the job which has been interrupted after deleting the messages.
+func interruptAfter(job Job, messageIDs ...snowflake.ID) Record {
+	record := NewRecord(1, job.ChannelID, 2, ModeAdvanced)
+	job = record.Track(job)
+	job.Deleted(messageIDs)
+	record.Finish(len(messageIDs), ErrInterrupted)
+	return *record
+}
+
+func TestCheckpointKeepsExclusionsAcrossResumes(t *testing.T) {
+	now := time.Now()
+	id := func(minutes int) snowflake.ID {
+		return snowflake.New(now.Add(-time.Duration(minutes) * time.Minute))
+	}
+	controller := NewController()
+	p, err := controller.CreatePurge(1, 10, discord.ChannelTypeGuildText, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller.SetMode(p, ModeAdvanced, 0)
+	controller.SetStartID(p, id(0))
+	controller.SetEndID(p, id(100))
+	controller.ExcludeMessage(p, id(80))
+	controller.ExcludeRange(p, id(60), id(50))
+
+	first, ok := NewCheckpoint(1, interruptAfter(p.Job(), id(10), id(20)), p.Job())
+	if !ok {
+		t.Fatal("no checkpoint after the first interruption")
+	}
+	resumed := first.Job(nil)
+	second, ok := NewCheckpoint(2, interruptAfter(resumed, id(30), id(40)), resumed)
+	if !ok {
+		t.Fatal("no checkpoint after the second interruption")
+	}
+	if second.StartID != id(40) {
+		t.Errorf("second checkpoint starts at %d, want after the last deleted message %d", second.StartID, id(40))
+	}
+
+	keep := second.Job(nil).Keep
+	for _, messageID := range []snowflake.ID{id(80), id(55)} {
+		if !keep(discord.Message{ID: messageID}) {
+			t.Errorf("the twice resumed purge does not keep the excluded message %d", messageID)
+		}
+	}
+	if keep(discord.Message{ID: id(70)}) {
+		t.Errorf("the twice resumed purge keeps the message %d which is not excluded", id(70))
+	}
+}
@@ -1,41 +1,240 @@
 package purge
 
 import (
+	"context"
+	"errors"
 	"slices"
+	"sync"
 	"time"
 
+	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/snowflake/v2"
 )
 
 var (
+	ErrSetupGone   = errors.New("the purge setup does not exist anymore")
+	ErrSetupBusy   = errors.New("the purge is running or waiting for approval")
+	ErrSetupExists = errors.New("the user already has a purge setup in progress")
+	ErrRangeTooOld = errors.New("messages cannot be older than 2 weeks")
+
 	durationDay = 24 * time.Hour
 )
 
+// purgeKey identifies a purge setup, every user can set up one purge per channel.
+type purgeKey struct {
+	channelID snowflake.ID
+	userID    snowflake.ID
+}
+
 type Controller struct {
-	purges map[snowflake.ID]*Purge
+	purges map[purgeKey]*Purge
+	// runs holds the cancel funcs of the running executions.
+	runs        map[uint64]context.CancelCauseFunc
+	nextRunID   uint64
+	interrupted bool
+	mu          sync.Mutex
 }
 
 func NewController() *Controller {
 	return &Controller{
-		purges: make(map[snowflake.ID]*Purge),
+		purges: make(map[purgeKey]*Purge),
+		runs:   make(map[uint64]context.CancelCauseFunc),
+	}
+}
+
+func (c *Controller) Purge(channelID, userID snowflake.ID) *Purge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.purges[purgeKey{channelID, userID}]
+}
+
+// Running reports whether the purge is being executed.
+func (c *Controller) Running(purge *Purge) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return purge.Running
+}
+
+// Pending reports whether the purge waits for the approval of a second moderator.
+func (c *Controller) Pending(purge *Purge) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return purge.Pending()
+}
+
+// Approval returns a copy of the purge's approval request and reports false if the purge does not wait for an approval.
+func (c *Controller) Approval(purge *Purge) (Approval, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !purge.Pending() {
+		return Approval{}, false
+	}
+	return *purge.Approval, true
+}
+
+// Purges returns all purge setups in the channel.
+func (c *Controller) Purges(channelID snowflake.ID) []*Purge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.channelPurges(channelID)
+}
+
+// channelPurges returns all purge setups in the channel, oldest activity first. c.mu has to be held.
+func (c *Controller) channelPurges(channelID snowflake.ID) []*Purge {
+	var purges []*Purge
+	for key, purge := range c.purges {
+		if key.channelID == channelID {
+			purges = append(purges, purge)
+		}
+	}
+	slices.SortFunc(purges, func(a, b *Purge) int {
+		return a.lastActivity.Compare(b.lastActivity)
+	})
+	return purges
+}
+
+// Len returns the amount of purge setups.
+func (c *Controller) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.purges)
+}
+
+// StartRun tracks an execution until the returned func is called. The returned context is cancelled with
+// ErrInterrupted once the executions are interrupted.
+func (c *Controller) StartRun(ctx context.Context) (context.Context, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, cancel := context.WithCancelCause(ctx)
+	if c.interrupted {
+		cancel(ErrInterrupted)
+	}
+	c.nextRunID++
+	id := c.nextRunID
+	c.runs[id] = cancel
+	return ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.runs, id)
+		cancel(nil)
+	}
+}
+
+// Runs returns the amount of running executions.
+func (c *Controller) Runs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.runs)
+}
+
+// Interrupt cancels all running and future executions with ErrInterrupted.
+func (c *Controller) Interrupt() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interrupted = true
+	for _, cancel := range c.runs {
+		cancel(ErrInterrupted)
 	}
 }
 
-func (c *Controller) Purge(channelID snowflake.ID) *Purge {
-	return c.purges[channelID]
+func (c *Controller) CreatePurge(guildID snowflake.ID, channelID snowflake.ID, channelType discord.ChannelType, userID snowflake.ID) (*Purge, error) {
+	if !SupportsMessages(channelType) {
+		return nil, ErrUnsupportedChannel
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	purge := &Purge{
+		GuildID:       guildID,
+		ChannelID:     channelID,
+		ChannelType:   channelType,
+		UserID:        userID,
+		CorrelationID: newRecordID(now),
+		lastActivity:  now,
+	}
+	c.purges[purgeKey{channelID, userID}] = purge
+	return purge, nil
 }
 
-func (c *Controller) CreatePurge(channelID, userID snowflake.ID) {
-	c.purges[channelID] = &Purge{
-		UserID: userID,
+// TakeOver reassigns the purge setup to userID and returns the ID of the previous owner. A setup of userID in the
+// channel which has not chosen a mode yet is discarded and returned, any other setup makes the take over fail.
+// A nil purge has been removed in the meantime.
+func (c *Controller) TakeOver(purge *Purge, userID snowflake.ID) (snowflake.ID, *Purge, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if purge == nil {
+		return 0, nil, ErrSetupGone
+	}
+	previousID := purge.UserID
+	if c.purges[purgeKey{purge.ChannelID, previousID}] != purge {
+		return 0, nil, ErrSetupGone
+	}
+	if purge.Running || purge.Pending() {
+		return 0, nil, ErrSetupBusy
 	}
+	own, ok := c.purges[purgeKey{purge.ChannelID, userID}]
+	if ok && (own.Mode != "" || own.Running || own.Pending()) {
+		return 0, nil, ErrSetupExists
+	}
+	delete(c.purges, purgeKey{purge.ChannelID, previousID})
+	purge.UserID = userID
+	purge.lastActivity = time.Now()
+	c.purges[purgeKey{purge.ChannelID, userID}] = purge
+	return previousID, own, nil
+}
+
+func (c *Controller) SetPromptID(purge *Purge, messageID snowflake.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.PromptID = messageID
+}
+
+// Touch marks the purge setup as active, postponing its expiry.
+func (c *Controller) Touch(purge *Purge) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.lastActivity = time.Now()
+}
+
+func (c *Controller) SetRunning(purge *Purge, running bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.Running = running
+}
+
+func (c *Controller) SetMode(purge *Purge, mode Mode, maxCount int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.Mode = mode
+	purge.MaxCount = maxCount
+}
+
+func (c *Controller) SetReactions(purge *Purge, filter ReactionFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.Reactions = filter
+}
+
+func (c *Controller) SetBulkLimit(purge *Purge, limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.BulkLimit = limit
 }
 
-func (c *Controller) SetBulkLimit(channelID snowflake.ID, limit int) {
-	c.purges[channelID].BulkLimit = limit
+// SetAmount sets up a simple purge of the latest amount messages which are young enough to be bulk deleted.
+func (c *Controller) SetAmount(purge *Purge, amount int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	purge.StartID = snowflake.New(now)
+	purge.EndID = snowflake.New(now.Add(-14 * durationDay))
+	purge.MaxCount = amount
+	purge.BulkLimit = min(amount, 100)
 }
 
 func (c *Controller) SetStartID(purge *Purge, messageID snowflake.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if time.Now().Sub(messageID.Time()) > 14*durationDay {
 		return false
 	}
@@ -44,6 +243,8 @@ func (c *Controller) SetStartID(purge *Purge, messageID snowflake.ID) bool {
 }
 
 func (c *Controller) SetEndID(purge *Purge, messageID snowflake.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if messageID > purge.StartID {
 		if messageID.Time().Sub(purge.StartID.Time()) > 14*durationDay {
 			return false
@@ -56,22 +257,234 @@ func (c *Controller) SetEndID(purge *Purge, messageID snowflake.ID) bool {
 	return true
 }
 
+// ExcludeMessage excludes the message and reports false if it already is excluded on its own.
 func (c *Controller) ExcludeMessage(purge *Purge, messageID snowflake.ID) bool {
-	if slices.Contains(purge.exclude, messageID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.excludeMessage(purge, messageID)
+}
+
+// excludeMessage excludes the message and reports false if it already is excluded on its own. c.mu has to be held.
+func (c *Controller) excludeMessage(purge *Purge, messageID snowflake.ID) bool {
+	if _, ok := purge.excluded[messageID]; ok {
+		return false
+	}
+	if purge.excluded == nil {
+		purge.excluded = make(map[snowflake.ID]struct{})
+	}
+	purge.excluded[messageID] = struct{}{}
+	delete(purge.included, messageID)
+	return true
+}
+
+// IncludeMessage takes the message back into the purge and reports false if it is not excluded.
+func (c *Controller) IncludeMessage(purge *Purge, message discord.Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !purge.Excluded()(message) {
+		return false
+	}
+	c.include(purge, message.ID)
+	return true
+}
+
+// include removes the message from the single excluded messages and keeps it out of excluded ranges and filters.
+// c.mu has to be held.
+func (c *Controller) include(purge *Purge, messageID snowflake.ID) {
+	delete(purge.excluded, messageID)
+	if purge.included == nil {
+		purge.included = make(map[snowflake.ID]struct{})
+	}
+	purge.included[messageID] = struct{}{}
+}
+
+// ExcludeRange keeps all messages between low and high inclusive.
+func (c *Controller) ExcludeRange(purge *Purge, low snowflake.ID, high snowflake.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.ranges = append(purge.ranges, ExcludedRange{Low: low, High: high})
+}
+
+// ExcludeFilter keeps all messages matching the filter.
+func (c *Controller) ExcludeFilter(purge *Purge, filter ExclusionFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.excludeFilter(purge, filter)
+}
+
+// excludeFilter keeps all messages matching the filter. c.mu has to be held.
+func (c *Controller) excludeFilter(purge *Purge, filter ExclusionFilter) {
+	purge.nextFilterID++
+	filter.ID = purge.nextFilterID
+	purge.filters = append(purge.filters, filter)
+}
+
+// RemoveExclusions removes the given single excluded messages, excluded ranges and filters.
+func (c *Controller) RemoveExclusions(purge *Purge, messageIDs []snowflake.ID, ranges []ExcludedRange, filterIDs []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, messageID := range messageIDs {
+		delete(purge.excluded, messageID)
+	}
+	purge.ranges = slices.DeleteFunc(purge.ranges, func(r ExcludedRange) bool {
+		return slices.Contains(ranges, r)
+	})
+	purge.filters = slices.DeleteFunc(purge.filters, func(filter ExclusionFilter) bool {
+		return slices.Contains(filterIDs, filter.ID)
+	})
+}
+
+// Mark excludes the messages marked to keep and includes the messages marked to purge, even if they have been excluded.
+func (c *Controller) Mark(purge *Purge, purgeIDs []snowflake.ID, keepIDs []snowflake.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, messageID := range keepIDs {
+		c.excludeMessage(purge, messageID)
+	}
+	for _, messageID := range purgeIDs {
+		c.include(purge, messageID)
+	}
+}
+
+// Overlapping returns all other purge setups in the purge's channel whose range overlaps with the purge's range.
+func (c *Controller) Overlapping(purge *Purge) []*Purge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var overlapping []*Purge
+	for _, other := range c.channelPurges(purge.ChannelID) {
+		if other != purge && purge.Overlaps(other) {
+			overlapping = append(overlapping, other)
+		}
+	}
+	return overlapping
+}
+
+// Merge extends the purge's range to cover the ranges of others, takes over their exclusions and removes them.
+// Nothing is merged if one of the others is running or waiting for approval or if the merged range reaches past
+// the messages which can be bulk deleted.
+func (c *Controller) Merge(purge *Purge, others ...*Purge) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	low, high := purge.Range()
+	for _, other := range others {
+		if other.Running || other.Pending() {
+			return ErrSetupBusy
+		}
+		otherLow, otherHigh := other.Range()
+		low, high = min(low, otherLow), max(high, otherHigh)
+	}
+	if time.Since(low.Time()) > 14*durationDay {
+		return ErrRangeTooOld
+	}
+	for _, other := range others {
+		if other.HasExclusions() {
+			exclusions := other.Exclusions()
+			c.excludeFilter(purge, ExclusionFilter{
+				Description: "exclusions merged from " + discord.UserMention(other.UserID),
+				Exclude:     other.Excluded(),
+				Merged:      &exclusions,
+			})
+		}
+		delete(c.purges, purgeKey{other.ChannelID, other.UserID})
+	}
+	if purge.Forwards {
+		purge.StartID, purge.EndID = low, high
+	} else {
+		purge.StartID, purge.EndID = high, low
+	}
+	return nil
+}
+
+// RequestApproval marks the purge as waiting for a second moderator to approve purging count messages.
+func (c *Controller) RequestApproval(purge *Purge, count int, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge.Approval = &Approval{
+		ChannelID: purge.ChannelID,
+		UserID:    purge.UserID,
+		Count:     count,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// SetApprovalRequest records the message asking for the purge's approval.
+func (c *Controller) SetApprovalRequest(purge *Purge, channelID snowflake.ID, messageID snowflake.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if purge.Approval != nil {
+		purge.Approval.RequestChannelID = channelID
+		purge.Approval.RequestID = messageID
+	}
+}
+
+// Approve records the approver of the purge and reports false if the purge does not wait for an approval.
+func (c *Controller) Approve(purge *Purge, approverID snowflake.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !purge.Pending() {
 		return false
 	}
-	purge.exclude = append(purge.exclude, messageID)
+	purge.Approval.ApproverID = approverID
 	return true
 }
 
-func (c *Controller) IncludeMessage(purge *Purge, messageID snowflake.ID) bool {
-	if !slices.Contains(purge.exclude, messageID) {
+// Reject withdraws the approval request of the purge and reports false if the purge does not wait for an approval.
+func (c *Controller) Reject(purge *Purge) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !purge.Pending() {
 		return false
 	}
-	purge.include = append(purge.include, messageID)
+	purge.Approval = nil
 	return true
 }
 
-func (c *Controller) RemovePurge(channelID snowflake.ID) {
-	delete(c.purges, channelID)
+// ExpireApprovals withdraws all approval requests which have not been answered in time and returns copies of their
+// purges with the expired approvals.
+func (c *Controller) ExpireApprovals() []Purge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expired []Purge
+	for _, purge := range c.purges {
+		if !purge.Pending() || time.Now().Before(purge.Approval.ExpiresAt) {
+			continue
+		}
+		expired = append(expired, *purge)
+		purge.Approval = nil
+	}
+	return expired
+}
+
+func (c *Controller) RemovePurge(channelID, userID snowflake.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.purges, purgeKey{channelID, userID})
+}
+
+// ForceCancel removes the purge setup of the user unless it is running and returns a copy of it.
+func (c *Controller) ForceCancel(channelID, userID snowflake.ID) (Purge, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	purge, ok := c.purges[purgeKey{channelID, userID}]
+	if !ok || purge.Running {
+		return Purge{}, false
+	}
+	delete(c.purges, purgeKey{channelID, userID})
+	return *purge, true
+}
+
+// Expire removes all purge setups which have been idle for longer than timeout and returns copies of them.
+// Running purges and purges waiting for approval are never expired.
+func (c *Controller) Expire(timeout time.Duration) []Purge {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expired []Purge
+	for key, purge := range c.purges {
+		if purge.Running || purge.Pending() || time.Since(purge.lastActivity) < timeout {
+			continue
+		}
+		expired = append(expired, *purge)
+		delete(c.purges, key)
+	}
+	return expired
 }
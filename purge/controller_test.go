@@ -0,0 +1,268 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// newTestPurge creates the purge setup of the user in the channel.
+func newTestPurge(t *testing.T, controller *Controller, channelID snowflake.ID, userID snowflake.ID) *Purge {
+	t.Helper()
+	p, err := controller.CreatePurge(1, channelID, discord.ChannelTypeGuildText, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestControllerExpire(t *testing.T) {
+	tests := []struct {
+		name   string
+		idle   time.Duration
+		modify func(controller *Controller, p *Purge)
+		want   bool
+	}{
+		{name: "active", idle: time.Minute, want: false},
+		{name: "idle", idle: time.Hour, want: true},
+		{name: "running", idle: time.Hour, modify: func(controller *Controller, p *Purge) {
+			controller.SetRunning(p, true)
+		}, want: false},
+		{name: "pending", idle: time.Hour, modify: func(controller *Controller, p *Purge) {
+			controller.RequestApproval(p, 10, time.Now().Add(time.Hour))
+		}, want: false},
+		{name: "approved", idle: time.Hour, modify: func(controller *Controller, p *Purge) {
+			controller.RequestApproval(p, 10, time.Now().Add(time.Hour))
+			controller.Approve(p, 3)
+		}, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := NewController()
+			p := newTestPurge(t, controller, 10, 2)
+			if test.modify != nil {
+				test.modify(controller, p)
+			}
+			p.lastActivity = time.Now().Add(-test.idle)
+
+			expired := controller.Expire(30 * time.Minute)
+			if got := len(expired) == 1; got != test.want {
+				t.Fatalf("Expire = %d setups, want expired %t", len(expired), test.want)
+			}
+			if exists := controller.Purge(10, 2) != nil; exists == test.want {
+				t.Errorf("setup still exists = %t after expiring it = %t", exists, test.want)
+			}
+			if test.want && (expired[0].ChannelID != 10 || expired[0].UserID != 2) {
+				t.Errorf("Expire = %+v, want a copy of the expired setup", expired[0])
+			}
+		})
+	}
+}
+
+func TestControllerTouchPostponesExpiry(t *testing.T) {
+	controller := NewController()
+	p := newTestPurge(t, controller, 10, 2)
+	p.lastActivity = time.Now().Add(-time.Hour)
+	controller.Touch(p)
+	if expired := controller.Expire(30 * time.Minute); len(expired) != 0 {
+		t.Errorf("Expire = %d setups, want the touched setup to be kept", len(expired))
+	}
+}
+
+func TestControllerTakeOver(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, controller *Controller) *Purge
+		want    error
+		discard bool
+	}{
+		{name: "idle setup", setup: func(t *testing.T, controller *Controller) *Purge {
+			return newTestPurge(t, controller, 10, 2)
+		}},
+		{name: "removed setup", setup: func(t *testing.T, controller *Controller) *Purge {
+			p := newTestPurge(t, controller, 10, 2)
+			controller.RemovePurge(10, 2)
+			return p
+		}, want: ErrSetupGone},
+		{name: "nil setup", setup: func(*testing.T, *Controller) *Purge {
+			return nil
+		}, want: ErrSetupGone},
+		{name: "running setup", setup: func(t *testing.T, controller *Controller) *Purge {
+			p := newTestPurge(t, controller, 10, 2)
+			controller.SetRunning(p, true)
+			return p
+		}, want: ErrSetupBusy},
+		{name: "pending setup", setup: func(t *testing.T, controller *Controller) *Purge {
+			p := newTestPurge(t, controller, 10, 2)
+			controller.RequestApproval(p, 10, time.Now().Add(time.Hour))
+			return p
+		}, want: ErrSetupBusy},
+		{name: "own fresh setup", setup: func(t *testing.T, controller *Controller) *Purge {
+			newTestPurge(t, controller, 10, 3)
+			return newTestPurge(t, controller, 10, 2)
+		}, discard: true},
+		{name: "own setup with a mode", setup: func(t *testing.T, controller *Controller) *Purge {
+			own := newTestPurge(t, controller, 10, 3)
+			controller.SetMode(own, ModeSimple, 0)
+			return newTestPurge(t, controller, 10, 2)
+		}, want: ErrSetupExists},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := NewController()
+			p := test.setup(t, controller)
+			previousID, discarded, err := controller.TakeOver(p, 3)
+			if err != test.want {
+				t.Fatalf("TakeOver = %v, want %v", err, test.want)
+			}
+			if err != nil {
+				return
+			}
+			if previousID != 2 {
+				t.Errorf("previous owner = %d, want 2", previousID)
+			}
+			if (discarded != nil) != test.discard {
+				t.Errorf("discarded = %v, want a discarded setup %t", discarded, test.discard)
+			}
+			if controller.Purge(10, 3) != p || controller.Purge(10, 2) != nil {
+				t.Error("the setup has not been moved to the new owner")
+			}
+		})
+	}
+}
+
+func TestControllerForceCancel(t *testing.T) {
+	controller := NewController()
+	p := newTestPurge(t, controller, 10, 2)
+	controller.SetRunning(p, true)
+	if _, ok := controller.ForceCancel(10, 2); ok {
+		t.Fatal("force canceled a running purge")
+	}
+	controller.SetRunning(p, false)
+	controller.RequestApproval(p, 10, time.Now().Add(time.Hour))
+	canceled, ok := controller.ForceCancel(10, 2)
+	if !ok || !canceled.Pending() {
+		t.Fatalf("ForceCancel = %+v, %t, want a copy of the pending setup", canceled, ok)
+	}
+	if controller.Purge(10, 2) != nil {
+		t.Error("the force canceled setup still exists")
+	}
+	if _, ok := controller.ForceCancel(10, 2); ok {
+		t.Error("force canceled a removed setup")
+	}
+}
+
+func TestControllerOverlapping(t *testing.T) {
+	now := time.Now()
+	id := func(minutes int) snowflake.ID {
+		return snowflake.New(now.Add(-time.Duration(minutes) * time.Minute))
+	}
+	tests := []struct {
+		name       string
+		start, end int
+		channelID  snowflake.ID
+		want       bool
+	}{
+		{name: "inside", start: 20, end: 30, channelID: 10, want: true},
+		{name: "around", start: 0, end: 100, channelID: 10, want: true},
+		{name: "touching", start: 50, end: 60, channelID: 10, want: true},
+		{name: "forwards", start: 60, end: 40, channelID: 10, want: true},
+		{name: "before", start: 51, end: 60, channelID: 10, want: false},
+		{name: "after", start: 0, end: 9, channelID: 10, want: false},
+		{name: "other channel", start: 20, end: 30, channelID: 11, want: false},
+		{name: "no range", channelID: 10, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := NewController()
+			p := newTestPurge(t, controller, 10, 2)
+			controller.SetStartID(p, id(10))
+			controller.SetEndID(p, id(50))
+			other := newTestPurge(t, controller, test.channelID, 3)
+			if test.start != 0 || test.end != 0 {
+				controller.SetStartID(other, id(test.start))
+				controller.SetEndID(other, id(test.end))
+			}
+			overlapping := controller.Overlapping(p)
+			if got := len(overlapping) == 1 && overlapping[0] == other; got != test.want {
+				t.Errorf("Overlapping = %v, want the other setup %t", overlapping, test.want)
+			}
+		})
+	}
+}
+
+func TestControllerMerge(t *testing.T) {
+	now := time.Now()
+	id := func(minutes int) snowflake.ID {
+		return snowflake.New(now.Add(-time.Duration(minutes) * time.Minute))
+	}
+	newRange := func(t *testing.T, controller *Controller, userID snowflake.ID, start int, end int) *Purge {
+		p := newTestPurge(t, controller, 10, userID)
+		controller.SetStartID(p, id(start))
+		controller.SetEndID(p, id(end))
+		return p
+	}
+
+	t.Run("merges ranges and exclusions", func(t *testing.T) {
+		controller := NewController()
+		p := newRange(t, controller, 2, 10, 50)
+		other := newRange(t, controller, 3, 40, 90)
+		controller.ExcludeMessage(other, id(80))
+		if err := controller.Merge(p, other); err != nil {
+			t.Fatal(err)
+		}
+		if p.StartID != id(10) || p.EndID != id(90) {
+			t.Errorf("merged range = %d to %d, want %d to %d", p.StartID, p.EndID, id(10), id(90))
+		}
+		if !p.Excluded()(discord.Message{ID: id(80)}) {
+			t.Error("the merged purge does not keep the exclusion of the other setup")
+		}
+		if controller.Purge(10, 3) != nil {
+			t.Error("the merged setup still exists")
+		}
+	})
+
+	t.Run("keeps the direction", func(t *testing.T) {
+		controller := NewController()
+		p := newRange(t, controller, 2, 50, 10)
+		other := newRange(t, controller, 3, 5, 20)
+		if err := controller.Merge(p, other); err != nil {
+			t.Fatal(err)
+		}
+		if !p.Forwards || p.StartID != id(50) || p.EndID != id(5) {
+			t.Errorf("merged range = %d to %d forwards %t, want %d to %d forwards", p.StartID, p.EndID, p.Forwards, id(50), id(5))
+		}
+	})
+
+	tests := []struct {
+		name   string
+		modify func(controller *Controller, other *Purge)
+		want   error
+	}{
+		{name: "running", modify: func(controller *Controller, other *Purge) {
+			controller.SetRunning(other, true)
+		}, want: ErrSetupBusy},
+		{name: "pending", modify: func(controller *Controller, other *Purge) {
+			controller.RequestApproval(other, 10, time.Now().Add(time.Hour))
+		}, want: ErrSetupBusy},
+		{name: "too old", modify: func(_ *Controller, other *Purge) {
+			other.EndID = snowflake.New(now.Add(-15 * durationDay))
+		}, want: ErrRangeTooOld},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := NewController()
+			p := newRange(t, controller, 2, 10, 50)
+			other := newRange(t, controller, 3, 40, 90)
+			test.modify(controller, other)
+			if err := controller.Merge(p, other); err != test.want {
+				t.Fatalf("Merge = %v, want %v", err, test.want)
+			}
+			if p.StartID != id(10) || p.EndID != id(50) || controller.Purge(10, 3) == nil {
+				t.Error("a failed merge changed the setups")
+			}
+		})
+	}
+}
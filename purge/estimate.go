@@ -0,0 +1,48 @@
+package purge
+
+import (
+	"context"
+	"errors"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+)
+
+var errEstimated = errors.New("estimated")
+
+// Estimate counts the messages the job would purge, up to its max count. Ranges covered by the index are counted
+// without paging the channel.
+func Estimate(ctx context.Context, client rest.Rest, index *Index, job Job) (int, error) {
+	var count int
+	low, high := min(job.StartID, job.EndID), max(job.StartID, job.EndID)
+	if messages, ok := index.Messages(job.ChannelID, low, high); ok {
+		for _, indexed := range messages {
+			if indexed.ID == job.StartID {
+				continue
+			}
+			if !job.Kept(indexed.message(job.ChannelID)) {
+				count++
+			}
+		}
+		if job.MaxCount > 0 {
+			count = min(count, job.MaxCount)
+		}
+		return count, nil
+	}
+	err := Scan(ctx, client, job, func(messages []discord.Message) error {
+		for _, message := range messages {
+			if !job.Kept(message) {
+				count++
+			}
+		}
+		if job.MaxCount > 0 && count >= job.MaxCount {
+			count = job.MaxCount
+			return errEstimated
+		}
+		return nil
+	})
+	if errors.Is(err, errEstimated) {
+		err = nil
+	}
+	return count, err
+}
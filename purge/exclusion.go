@@ -0,0 +1,118 @@
+package purge
+
+import (
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Exclusion reports whether a message is excluded from a purge.
+type Exclusion func(message discord.Message) bool
+
+// Or returns an exclusion which excludes messages excluded by either exclusion.
+func (e Exclusion) Or(other Exclusion) Exclusion {
+	return func(message discord.Message) bool {
+		return e(message) || other(message)
+	}
+}
+
+// idSet returns the IDs as a set, so exclusions do not search them for every message.
+func idSet(ids []snowflake.ID) map[snowflake.ID]struct{} {
+	set := make(map[snowflake.ID]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// ExcludeMessages excludes the given messages.
+func ExcludeMessages(messageIDs ...snowflake.ID) Exclusion {
+	excluded := idSet(messageIDs)
+	return func(message discord.Message) bool {
+		_, ok := excluded[message.ID]
+		return ok
+	}
+}
+
+// ExcludeRange excludes all messages between low and high inclusive.
+func ExcludeRange(low snowflake.ID, high snowflake.ID) Exclusion {
+	return func(message discord.Message) bool {
+		return message.ID >= low && message.ID <= high
+	}
+}
+
+// ExcludeAuthors excludes the messages of the given users.
+func ExcludeAuthors(userIDs ...snowflake.ID) Exclusion {
+	excluded := idSet(userIDs)
+	return func(message discord.Message) bool {
+		_, ok := excluded[message.Author.ID]
+		return ok
+	}
+}
+
+// RolesFunc returns the role IDs of a member of the purge's guild.
+type RolesFunc func(userID snowflake.ID) []snowflake.ID
+
+// ExcludeRoles excludes the messages of members holding any of the given roles.
+func ExcludeRoles(roles RolesFunc, roleIDs ...snowflake.ID) Exclusion {
+	excluded := idSet(roleIDs)
+	return func(message discord.Message) bool {
+		for _, roleID := range roles(message.Author.ID) {
+			if _, ok := excluded[roleID]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ExcludedRange is a sub-range of a purge's range which is kept.
+type ExcludedRange struct {
+	Low  snowflake.ID `json:"low"`
+	High snowflake.ID `json:"high"`
+}
+
+// ExclusionFilter excludes the messages matching Exclude, Description explains which ones. The ID is assigned
+// when the filter is added to a purge. AuthorIDs, RoleIDs and Merged describe Exclude so it can be rebuilt once
+// the filter has been stored.
+type ExclusionFilter struct {
+	ID          int            `json:"id"`
+	Description string         `json:"description"`
+	Exclude     Exclusion      `json:"-"`
+	AuthorIDs   []snowflake.ID `json:"author_ids,omitempty"`
+	RoleIDs     []snowflake.ID `json:"role_ids,omitempty"`
+	Merged      *Exclusions    `json:"merged,omitempty"`
+}
+
+// Exclusions are the stored form of a purge's exclusions.
+type Exclusions struct {
+	MessageIDs  []snowflake.ID    `json:"message_ids,omitempty"`
+	IncludedIDs []snowflake.ID    `json:"included_ids,omitempty"`
+	Ranges      []ExcludedRange   `json:"ranges,omitempty"`
+	Filters     []ExclusionFilter `json:"filters,omitempty"`
+}
+
+// Exclusion rebuilds the exclusion, roles resolves the roles of members for role filters.
+func (e Exclusions) Exclusion(roles RolesFunc) Exclusion {
+	exclusion := ExcludeMessages(e.MessageIDs...)
+	for _, r := range e.Ranges {
+		exclusion = exclusion.Or(ExcludeRange(r.Low, r.High))
+	}
+	for _, filter := range e.Filters {
+		if len(filter.AuthorIDs) > 0 {
+			exclusion = exclusion.Or(ExcludeAuthors(filter.AuthorIDs...))
+		}
+		if len(filter.RoleIDs) > 0 {
+			exclusion = exclusion.Or(ExcludeRoles(roles, filter.RoleIDs...))
+		}
+		if filter.Merged != nil {
+			exclusion = exclusion.Or(filter.Merged.Exclusion(roles))
+		}
+	}
+	included := idSet(e.IncludedIDs)
+	return func(message discord.Message) bool {
+		if _, ok := included[message.ID]; ok {
+			return false
+		}
+		return exclusion(message)
+	}
+}
@@ -0,0 +1,89 @@
+package purge
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func authoredMessage(id snowflake.ID, authorID snowflake.ID) discord.Message {
+	return discord.Message{ID: id, Author: discord.User{ID: authorID}}
+}
+
+func TestExclusions(t *testing.T) {
+	roles := func(userID snowflake.ID) []snowflake.ID {
+		if userID == 21 {
+			return []snowflake.ID{30, 31}
+		}
+		return nil
+	}
+	tests := []struct {
+		name      string
+		exclusion Exclusion
+		message   discord.Message
+		want      bool
+	}{
+		{"excluded message", ExcludeMessages(1, 2), authoredMessage(2, 20), true},
+		{"other message", ExcludeMessages(1, 2), authoredMessage(3, 20), false},
+		{"no excluded messages", ExcludeMessages(), authoredMessage(1, 20), false},
+		{"range low bound", ExcludeRange(5, 10), authoredMessage(5, 20), true},
+		{"range high bound", ExcludeRange(5, 10), authoredMessage(10, 20), true},
+		{"outside range", ExcludeRange(5, 10), authoredMessage(11, 20), false},
+		{"excluded author", ExcludeAuthors(20, 22), authoredMessage(1, 22), true},
+		{"other author", ExcludeAuthors(20, 22), authoredMessage(1, 21), false},
+		{"excluded role", ExcludeRoles(roles, 31), authoredMessage(1, 21), true},
+		{"other role", ExcludeRoles(roles, 32), authoredMessage(1, 21), false},
+		{"member without roles", ExcludeRoles(roles, 31), authoredMessage(1, 20), false},
+		{"either exclusion", ExcludeMessages(1).Or(ExcludeAuthors(20)), authoredMessage(2, 20), true},
+		{"neither exclusion", ExcludeMessages(1).Or(ExcludeAuthors(20)), authoredMessage(2, 21), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.exclusion(tt.message); got != tt.want {
+				t.Errorf("exclusion = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExclusionsRebuild(t *testing.T) {
+	roles := func(userID snowflake.ID) []snowflake.ID {
+		if userID == 21 {
+			return []snowflake.ID{30}
+		}
+		return nil
+	}
+	exclusions := Exclusions{
+		MessageIDs:  []snowflake.ID{1},
+		IncludedIDs: []snowflake.ID{6, 7},
+		Ranges:      []ExcludedRange{{Low: 5, High: 8}},
+		Filters: []ExclusionFilter{
+			{ID: 1, AuthorIDs: []snowflake.ID{22}},
+			{ID: 2, RoleIDs: []snowflake.ID{30}},
+			{ID: 3, Merged: &Exclusions{MessageIDs: []snowflake.ID{12}, IncludedIDs: []snowflake.ID{1}}},
+		},
+	}
+	tests := []struct {
+		name    string
+		message discord.Message
+		want    bool
+	}{
+		{"excluded message", authoredMessage(1, 20), true},
+		{"excluded range", authoredMessage(5, 20), true},
+		{"included message in excluded range", authoredMessage(6, 20), false},
+		{"included message by excluded author", authoredMessage(7, 22), false},
+		{"excluded author", authoredMessage(9, 22), true},
+		{"excluded role", authoredMessage(9, 21), true},
+		{"merged excluded message", authoredMessage(12, 20), true},
+		{"not excluded", authoredMessage(10, 20), false},
+	}
+	exclusion := exclusions.Exclusion(roles)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exclusion(tt.message); got != tt.want {
+				t.Errorf("exclusion = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package purge
+
+import (
+	"advanced-purge/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Job describes a range of messages in a channel to purge. The start message itself is never purged.
+type Job struct {
+	ChannelID snowflake.ID
+	StartID   snowflake.ID
+	EndID     snowflake.ID
+	Forwards  bool
+	BulkLimit int
+	MaxCount  int
+	// AuthorIDs limits the purge to the messages of these authors if set.
+	AuthorIDs []snowflake.ID
+	// Reason is recorded in the guild's audit log for bulk deletes.
+	Reason string
+	// Keep reports whether a message in the range should not be purged.
+	Keep func(message discord.Message) bool
+	// Exclusions is the stored form of the exclusions Keep has been built from, checkpoints keep them.
+	Exclusions Exclusions
+	// Deleted is called with the IDs of each batch of deleted messages.
+	Deleted func(messageIDs []snowflake.ID)
+}
+
+// Kept reports whether the job keeps the message because of its authors or Keep.
+func (j Job) Kept(message discord.Message) bool {
+	if len(j.AuthorIDs) > 0 && !slices.Contains(j.AuthorIDs, message.Author.ID) {
+		return true
+	}
+	return j.Keep != nil && j.Keep(message)
+}
+
+// ProgressFunc is called after each purged batch, returning an error stops the execution.
+type ProgressFunc func(batch int, total int) error
+
+// Execute purges the job's messages batch by batch until the end of the range, the job's max count or the end
+// of the channel is reached. The amount of purged messages is returned even if the execution fails.
+func Execute(ctx context.Context, client rest.Rest, job Job, progress ProgressFunc) (int, error) {
+	bulkLimit := job.BulkLimit
+	if bulkLimit == 0 {
+		bulkLimit = 100
+	}
+	page := client.GetMessagesPage(job.ChannelID, job.StartID, bulkLimit, rest.WithCtx(ctx))
+	pageFunc := page.Previous
+	if job.Forwards {
+		pageFunc = page.Next
+	}
+	var total int
+	for i := 0; ; i++ {
+		if !pageFunc() {
+			if errors.Is(page.Err, rest.ErrNoMorePages) {
+				return total, nil
+			}
+			return total, fmt.Errorf("failed to fetch messages: %w", page.Err)
+		}
+		var done bool
+		messageIDs := make([]snowflake.ID, 0, len(page.Items))
+		for _, message := range page.Items {
+			if (job.Forwards && message.ID > job.EndID) || (!job.Forwards && message.ID < job.EndID) { // ignore if fetched but over the end message
+				done = true
+				continue
+			}
+			if message.ID == job.EndID {
+				done = true
+			}
+			if job.MaxCount > 0 && total+len(messageIDs) >= job.MaxCount {
+				done = true
+				break
+			}
+			if !job.Kept(message) {
+				messageIDs = append(messageIDs, message.ID)
+			}
+		}
+		if err := DeleteMessages(ctx, client, job.ChannelID, messageIDs, job.Reason); err != nil {
+			return total, fmt.Errorf("failed to delete messages: %w", err)
+		}
+		metrics.MessagesDeleted.Add(float64(len(messageIDs)))
+		if job.Deleted != nil && len(messageIDs) > 0 {
+			job.Deleted(messageIDs)
+		}
+		total += len(messageIDs)
+		if progress != nil {
+			if err := progress(i+1, total); err != nil {
+				return total, err
+			}
+		}
+		if done {
+			return total, nil
+		}
+	}
+}
+
+// bulkDeleteMaxAge is the maximum age of messages which can be bulk deleted, with some leeway for slow requests.
+const bulkDeleteMaxAge = 14*24*time.Hour - time.Minute
+
+// DeleteMessages deletes the messages in bulk if possible as bulk deletes require at least 2 messages which are
+// younger than 2 weeks. Older messages are deleted one by one. The reason is recorded in the guild's audit log.
+func DeleteMessages(ctx context.Context, client rest.Rest, channelID snowflake.ID, messageIDs []snowflake.ID, reason string) error {
+	opts := []rest.RequestOpt{rest.WithCtx(ctx)}
+	if reason != "" {
+		opts = append(opts, rest.WithReason(reason))
+	}
+	var bulk []snowflake.ID
+	for _, messageID := range messageIDs {
+		if time.Since(messageID.Time()) < bulkDeleteMaxAge {
+			bulk = append(bulk, messageID)
+			continue
+		}
+		if err := client.DeleteMessage(channelID, messageID, opts...); err != nil {
+			return err
+		}
+	}
+	switch len(bulk) {
+	case 0:
+		return nil
+	case 1:
+		return client.DeleteMessage(channelID, bulk[0], opts...)
+	default:
+		defer metrics.ObserveBulkDelete(time.Now())
+		return client.BulkDeleteMessages(channelID, bulk, opts...)
+	}
+}
@@ -0,0 +1,255 @@
+package purge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Duration is a time.Duration which is written like 30s or 5m in JSON.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// GuardConfig configures the anti-raid guard of a guild. Raids are detected within Window, a threshold of 0 disables
+// the respective detection. Detected raids are either purged right away or offered to purge in the mod-log channel.
+type GuardConfig struct {
+	ModLogChannelID   snowflake.ID `json:"mod_log_channel_id"`
+	AutoRun           bool         `json:"auto_run"`
+	Window            Duration     `json:"window"`
+	IdenticalMessages int          `json:"identical_messages"`
+	NewMemberMessages int          `json:"new_member_messages"`
+	NewMemberAge      Duration     `json:"new_member_age"`
+	Mentions          int          `json:"mentions"`
+}
+
+// GuardConfigs maps guild IDs to their guard configuration. Guilds without one are not guarded.
+type GuardConfigs map[snowflake.ID]GuardConfig
+
+func LoadGuardConfigs(path string) (GuardConfigs, error) {
+	configs := make(GuardConfigs)
+	if path == "" {
+		return configs, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse guard configs: %w", err)
+	}
+	return configs, nil
+}
+
+// GuardMessage is the part of a message the guard looks at. JoinedAt is zero if the author's join date is unknown.
+type GuardMessage struct {
+	ID        snowflake.ID
+	ChannelID snowflake.ID
+	AuthorID  snowflake.ID
+	JoinedAt  time.Time
+	Content   string
+	Mentions  int
+}
+
+// Raid is a detected flood of messages. It spans from the oldest flood message to the time it is purged. Reason is
+// the catalog key of the crossed threshold which is formatted with ReasonArgs.
+type Raid struct {
+	ID         snowflake.ID
+	GuildID    snowflake.ID
+	Reason     string
+	ReasonArgs []any
+	ChannelIDs []snowflake.ID
+	AuthorIDs  []snowflake.ID
+	FirstID    snowflake.ID
+}
+
+// Job returns the job purging the raid's authors' messages in one of its channels at the given time.
+func (r Raid) Job(now time.Time) Job {
+	return Job{
+		StartID:   snowflake.New(now),
+		EndID:     r.FirstID,
+		AuthorIDs: r.AuthorIDs,
+	}
+}
+
+// NewDetector creates a detector for the guard config. Detectors only depend on the observed messages' IDs for
+// timing, so they can be fed synthetic message streams.
+func NewDetector(config GuardConfig) *Detector {
+	return &Detector{config: config}
+}
+
+// Detector detects raids in a guild's stream of messages.
+type Detector struct {
+	config   GuardConfig
+	messages []GuardMessage
+	cooldown time.Time
+}
+
+// Observe adds the message to the window and returns a raid if the messages within the window cross a threshold.
+// After a detection the window is cleared and no raid is reported for another window.
+func (d *Detector) Observe(message GuardMessage) *Raid {
+	at := message.ID.Time()
+	window := time.Duration(d.config.Window)
+	expired := slices.IndexFunc(d.messages, func(m GuardMessage) bool {
+		return at.Sub(m.ID.Time()) <= window
+	})
+	if expired == -1 {
+		expired = len(d.messages)
+	}
+	d.messages = append(d.messages[expired:], message)
+	if at.Before(d.cooldown) {
+		return nil
+	}
+	reason, args, matched := d.detect(message)
+	if len(matched) == 0 {
+		return nil
+	}
+	d.messages = nil
+	d.cooldown = at.Add(window)
+	raid := &Raid{
+		Reason:     reason,
+		ReasonArgs: args,
+		FirstID:    matched[0].ID,
+	}
+	for _, m := range matched {
+		if !slices.Contains(raid.ChannelIDs, m.ChannelID) {
+			raid.ChannelIDs = append(raid.ChannelIDs, m.ChannelID)
+		}
+		if !slices.Contains(raid.AuthorIDs, m.AuthorID) {
+			raid.AuthorIDs = append(raid.AuthorIDs, m.AuthorID)
+		}
+		raid.FirstID = min(raid.FirstID, m.ID)
+	}
+	return raid
+}
+
+// detect checks the thresholds against the window, returning the reason with its arguments and the messages which make
+// up the raid.
+func (d *Detector) detect(message GuardMessage) (string, []any, []GuardMessage) {
+	if d.config.IdenticalMessages > 0 && message.Content != "" {
+		identical := d.filter(func(m GuardMessage) bool {
+			return m.Content == message.Content
+		})
+		if len(identical) >= d.config.IdenticalMessages {
+			return "guard.identical", []any{len(identical), time.Duration(d.config.Window)}, identical
+		}
+	}
+	if d.config.NewMemberMessages > 0 {
+		newMembers := d.filter(func(m GuardMessage) bool {
+			return !m.JoinedAt.IsZero() && m.ID.Time().Sub(m.JoinedAt) < time.Duration(d.config.NewMemberAge)
+		})
+		if len(newMembers) >= d.config.NewMemberMessages {
+			return "guard.new_members", []any{len(newMembers), time.Duration(d.config.NewMemberAge), time.Duration(d.config.Window)}, newMembers
+		}
+	}
+	if d.config.Mentions > 0 {
+		var mentions int
+		mentioning := d.filter(func(m GuardMessage) bool {
+			mentions += m.Mentions
+			return m.Mentions > 0
+		})
+		if mentions >= d.config.Mentions {
+			return "guard.mentions", []any{mentions, time.Duration(d.config.Window)}, mentioning
+		}
+	}
+	return "", nil, nil
+}
+
+func (d *Detector) filter(match func(m GuardMessage) bool) []GuardMessage {
+	var messages []GuardMessage
+	for _, m := range d.messages {
+		if match(m) {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// NewGuard creates a guard for the configured guilds.
+func NewGuard(configs GuardConfigs) *Guard {
+	return &Guard{
+		configs:   configs,
+		detectors: make(map[snowflake.ID]*Detector),
+		raids:     make(map[snowflake.ID]Raid),
+	}
+}
+
+// Guard runs a detector per configured guild and keeps the detected raids which wait for a moderator to purge them.
+type Guard struct {
+	configs   GuardConfigs
+	detectors map[snowflake.ID]*Detector
+	raids     map[snowflake.ID]Raid
+	mu        sync.Mutex
+}
+
+func (g *Guard) Config(guildID snowflake.ID) (GuardConfig, bool) {
+	config, ok := g.configs[guildID]
+	return config, ok
+}
+
+// Observe feeds the message to the guild's detector and returns a raid if one has been detected.
+func (g *Guard) Observe(guildID snowflake.ID, message GuardMessage) *Raid {
+	config, ok := g.configs[guildID]
+	if !ok {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	detector, ok := g.detectors[guildID]
+	if !ok {
+		detector = NewDetector(config)
+		g.detectors[guildID] = detector
+	}
+	raid := detector.Observe(message)
+	if raid != nil {
+		raid.ID = message.ID
+		raid.GuildID = guildID
+	}
+	return raid
+}
+
+// raidMaxAge is how long detected raids are kept for a moderator to purge them, their messages cannot be bulk
+// deleted after it.
+const raidMaxAge = 14 * 24 * time.Hour
+
+// AddRaid keeps the raid until it is taken to be purged or dismissed. Raids detected more than raidMaxAge before it
+// are forgotten.
+func (g *Guard) AddRaid(raid Raid) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id := range g.raids {
+		if raid.ID.Time().Sub(id.Time()) > raidMaxAge {
+			delete(g.raids, id)
+		}
+	}
+	g.raids[raid.ID] = raid
+}
+
+// TakeRaid removes the raid and returns it, reporting false if it has already been taken.
+func (g *Guard) TakeRaid(id snowflake.ID) (Raid, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	raid, ok := g.raids[id]
+	delete(g.raids, id)
+	return raid, ok
+}
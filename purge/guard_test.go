@@ -0,0 +1,208 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var guardEpoch = time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// guardMessage returns a message posted offset after guardEpoch. Detectors only time messages by their ID.
+func guardMessage(offset time.Duration, channelID snowflake.ID, authorID snowflake.ID, content string) GuardMessage {
+	return GuardMessage{
+		ID:        snowflake.New(guardEpoch.Add(offset)),
+		ChannelID: channelID,
+		AuthorID:  authorID,
+		Content:   content,
+	}
+}
+
+func TestDetectorIdenticalMessages(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window:            Duration(time.Minute),
+		IdenticalMessages: 3,
+	})
+	if raid := detector.Observe(guardMessage(0, 1, 10, "spam")); raid != nil {
+		t.Fatalf("detected a raid after 1 message: %+v", raid)
+	}
+	if raid := detector.Observe(guardMessage(time.Second, 2, 11, "hello")); raid != nil {
+		t.Fatalf("detected a raid after a different message: %+v", raid)
+	}
+	if raid := detector.Observe(guardMessage(2*time.Second, 2, 11, "spam")); raid != nil {
+		t.Fatalf("detected a raid after 2 identical messages: %+v", raid)
+	}
+	raid := detector.Observe(guardMessage(3*time.Second, 1, 12, "spam"))
+	if raid == nil {
+		t.Fatal("did not detect 3 identical messages")
+	}
+	if want := snowflake.New(guardEpoch); raid.FirstID != want {
+		t.Errorf("FirstID = %d, want %d", raid.FirstID, want)
+	}
+	if len(raid.AuthorIDs) != 3 {
+		t.Errorf("AuthorIDs = %v, want the 3 authors of the identical messages", raid.AuthorIDs)
+	}
+}
+
+func TestDetectorNewMemberMessages(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window:            Duration(time.Minute),
+		NewMemberMessages: 2,
+		NewMemberAge:      Duration(time.Hour),
+	})
+	old := guardMessage(0, 1, 10, "a")
+	old.JoinedAt = guardEpoch.Add(-2 * time.Hour)
+	unknown := guardMessage(time.Second, 1, 11, "b")
+	if raid := detector.Observe(old); raid != nil {
+		t.Fatalf("detected a raid of an old member: %+v", raid)
+	}
+	if raid := detector.Observe(unknown); raid != nil {
+		t.Fatalf("detected a raid of a member with an unknown join date: %+v", raid)
+	}
+	young := guardMessage(2*time.Second, 1, 12, "c")
+	young.JoinedAt = guardEpoch.Add(-time.Minute)
+	if raid := detector.Observe(young); raid != nil {
+		t.Fatalf("detected a raid after 1 message of a new member: %+v", raid)
+	}
+	younger := guardMessage(3*time.Second, 2, 13, "d")
+	younger.JoinedAt = guardEpoch
+	raid := detector.Observe(younger)
+	if raid == nil {
+		t.Fatal("did not detect 2 messages of new members")
+	}
+	if len(raid.AuthorIDs) != 2 || raid.AuthorIDs[0] != 12 || raid.AuthorIDs[1] != 13 {
+		t.Errorf("AuthorIDs = %v, want only the new members [12 13]", raid.AuthorIDs)
+	}
+}
+
+func TestDetectorMentions(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window:   Duration(time.Minute),
+		Mentions: 10,
+	})
+	first := guardMessage(0, 1, 10, "a")
+	first.Mentions = 6
+	if raid := detector.Observe(first); raid != nil {
+		t.Fatalf("detected a raid after 6 mentions: %+v", raid)
+	}
+	if raid := detector.Observe(guardMessage(time.Second, 1, 11, "b")); raid != nil {
+		t.Fatalf("detected a raid after a message without mentions: %+v", raid)
+	}
+	second := guardMessage(2*time.Second, 1, 12, "c")
+	second.Mentions = 4
+	raid := detector.Observe(second)
+	if raid == nil {
+		t.Fatal("did not detect 10 mentions")
+	}
+	if len(raid.AuthorIDs) != 2 {
+		t.Errorf("AuthorIDs = %v, want only the 2 mentioning authors", raid.AuthorIDs)
+	}
+}
+
+func TestDetectorDisabledThresholds(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window: Duration(time.Minute),
+	})
+	for i := range 20 {
+		message := guardMessage(time.Duration(i)*time.Second, 1, 10, "spam")
+		message.Mentions = 5
+		message.JoinedAt = guardEpoch
+		if raid := detector.Observe(message); raid != nil {
+			t.Fatalf("detected a raid without thresholds: %+v", raid)
+		}
+	}
+}
+
+func TestDetectorWindowSlides(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window:            Duration(time.Minute),
+		IdenticalMessages: 3,
+	})
+	detector.Observe(guardMessage(0, 1, 10, "spam"))
+	detector.Observe(guardMessage(30*time.Second, 1, 10, "spam"))
+	// the first message has left the window
+	if raid := detector.Observe(guardMessage(61*time.Second, 1, 10, "spam")); raid != nil {
+		t.Fatalf("detected a raid across more than the window: %+v", raid)
+	}
+	if len(detector.messages) != 2 {
+		t.Errorf("window holds %d messages, want 2", len(detector.messages))
+	}
+	raid := detector.Observe(guardMessage(62*time.Second, 1, 10, "spam"))
+	if raid == nil {
+		t.Fatal("did not detect 3 identical messages within the window")
+	}
+	if want := snowflake.New(guardEpoch.Add(30 * time.Second)); raid.FirstID != want {
+		t.Errorf("FirstID = %d, want the oldest message within the window %d", raid.FirstID, want)
+	}
+}
+
+func TestDetectorDeduplicates(t *testing.T) {
+	detector := NewDetector(GuardConfig{
+		Window:            Duration(time.Minute),
+		IdenticalMessages: 3,
+	})
+	detector.Observe(guardMessage(0, 1, 10, "spam"))
+	detector.Observe(guardMessage(time.Second, 2, 10, "spam"))
+	raid := detector.Observe(guardMessage(2*time.Second, 1, 10, "spam"))
+	if raid == nil {
+		t.Fatal("did not detect 3 identical messages")
+	}
+	if len(raid.ChannelIDs) != 2 || len(raid.AuthorIDs) != 1 {
+		t.Errorf("ChannelIDs = %v, AuthorIDs = %v, want 2 distinct channels and 1 distinct author", raid.ChannelIDs, raid.AuthorIDs)
+	}
+	// the same flood is not reported again within the next window
+	for i := 3; i < 10; i++ {
+		if raid := detector.Observe(guardMessage(time.Duration(i)*time.Second, 1, 10, "spam")); raid != nil {
+			t.Fatalf("reported the flood again after %d messages: %+v", i+1, raid)
+		}
+	}
+	// a flood going on after the cooldown is reported again, starting after the reported messages
+	raid = detector.Observe(guardMessage(63*time.Second, 1, 10, "spam"))
+	if raid == nil {
+		t.Fatal("did not report the flood again after the cooldown")
+	}
+	if want := snowflake.New(guardEpoch.Add(3 * time.Second)); raid.FirstID != want {
+		t.Errorf("FirstID = %d, want the first message after the reported raid %d", raid.FirstID, want)
+	}
+}
+
+func TestGuardObserve(t *testing.T) {
+	guard := NewGuard(GuardConfigs{
+		1: {Window: Duration(time.Minute), IdenticalMessages: 2},
+	})
+	if raid := guard.Observe(2, guardMessage(0, 1, 10, "spam")); raid != nil {
+		t.Fatalf("detected a raid in an unguarded guild: %+v", raid)
+	}
+	guard.Observe(2, guardMessage(time.Second, 1, 10, "spam"))
+	guard.Observe(1, guardMessage(0, 1, 10, "spam"))
+	last := guardMessage(time.Second, 1, 10, "spam")
+	raid := guard.Observe(1, last)
+	if raid == nil {
+		t.Fatal("did not detect 2 identical messages")
+	}
+	if raid.ID != last.ID || raid.GuildID != 1 {
+		t.Errorf("raid ID = %d, guild ID = %d, want %d and 1", raid.ID, raid.GuildID, last.ID)
+	}
+}
+
+func TestGuardRaids(t *testing.T) {
+	guard := NewGuard(nil)
+	old := Raid{ID: snowflake.New(guardEpoch)}
+	guard.AddRaid(old)
+	recent := Raid{ID: snowflake.New(guardEpoch.Add(raidMaxAge))}
+	guard.AddRaid(recent)
+	if _, ok := guard.raids[old.ID]; !ok {
+		t.Fatal("pruned a raid which is not older than the max age")
+	}
+	guard.AddRaid(Raid{ID: snowflake.New(guardEpoch.Add(raidMaxAge + time.Second))})
+	if _, ok := guard.raids[old.ID]; ok {
+		t.Error("kept a raid older than the max age")
+	}
+	if _, ok := guard.TakeRaid(recent.ID); !ok {
+		t.Fatal("did not take a kept raid")
+	}
+	if _, ok := guard.TakeRaid(recent.ID); ok {
+		t.Error("took a raid twice")
+	}
+}
@@ -0,0 +1,239 @@
+package purge
+
+import (
+	"advanced-purge/metrics"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// IndexedMessage holds the metadata of a message which purges filter by.
+type IndexedMessage struct {
+	ID       snowflake.ID
+	AuthorID snowflake.ID
+	Type     discord.MessageType
+	Flags    discord.MessageFlags
+	Pinned   bool
+}
+
+// NewIndex creates an index of the given channels which keeps at most limit messages per channel.
+func NewIndex(channelIDs []snowflake.ID, limit int) *Index {
+	channels := make(map[snowflake.ID]*channelIndex, len(channelIDs))
+	for _, channelID := range channelIDs {
+		channels[channelID] = &channelIndex{}
+	}
+	return &Index{
+		channels: channels,
+		limit:    limit,
+	}
+}
+
+// Index is a live index of the messages in a set of channels, fed by gateway message events. It only knows messages
+// posted after it started listening or after its oldest dropped message, whichever is later.
+type Index struct {
+	channels map[snowflake.ID]*channelIndex
+	limit    int
+	mu       sync.Mutex
+}
+
+type channelIndex struct {
+	// since is the oldest message ID the index is complete from.
+	since    snowflake.ID
+	messages []IndexedMessage
+}
+
+// message returns the indexed message as a message of the channel with the fields purges filter by.
+func (m IndexedMessage) message(channelID snowflake.ID) discord.Message {
+	return discord.Message{
+		ID:        m.ID,
+		ChannelID: channelID,
+		Author:    discord.User{ID: m.AuthorID},
+		Type:      m.Type,
+		Flags:     m.Flags,
+		Pinned:    m.Pinned,
+		CreatedAt: m.ID.Time(),
+	}
+}
+
+func compareIndexed(message IndexedMessage, id snowflake.ID) int {
+	return cmp.Compare(message.ID, id)
+}
+
+// Indexes reports whether the channel is indexed.
+func (i *Index) Indexes(channelID snowflake.ID) bool {
+	if i == nil {
+		return false
+	}
+	_, ok := i.channels[channelID]
+	return ok
+}
+
+// Since returns the oldest message ID the index of the channel is complete from and reports false if the channel is
+// not indexed.
+func (i *Index) Since(channelID snowflake.ID) (snowflake.ID, bool) {
+	if i == nil {
+		return 0, false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	channel, ok := i.channels[channelID]
+	if !ok {
+		return 0, false
+	}
+	return channel.since, true
+}
+
+// Reset forgets all messages and starts indexing from the given message ID, e.g. after a new gateway session
+// which may have missed events.
+func (i *Index) Reset(since snowflake.ID) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, channel := range i.channels {
+		channel.since = since
+		channel.messages = nil
+	}
+}
+
+// Put adds or updates the message if its channel is indexed.
+func (i *Index) Put(message discord.Message) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	channel, ok := i.channels[message.ChannelID]
+	if !ok || message.ID < channel.since {
+		return
+	}
+	indexed := IndexedMessage{
+		ID:       message.ID,
+		AuthorID: message.Author.ID,
+		Type:     message.Type,
+		Flags:    message.Flags,
+		Pinned:   message.Pinned,
+	}
+	n, found := slices.BinarySearchFunc(channel.messages, message.ID, compareIndexed)
+	if found {
+		channel.messages[n] = indexed
+		return
+	}
+	channel.messages = slices.Insert(channel.messages, n, indexed)
+	if len(channel.messages) > i.limit {
+		// drop the oldest message, the index is only complete from the next one on
+		channel.since = channel.messages[1].ID
+		channel.messages = slices.Delete(channel.messages, 0, 1)
+	}
+}
+
+// Remove removes the message from the index.
+func (i *Index) Remove(channelID snowflake.ID, messageID snowflake.ID) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	channel, ok := i.channels[channelID]
+	if !ok {
+		return
+	}
+	if n, found := slices.BinarySearchFunc(channel.messages, messageID, compareIndexed); found {
+		channel.messages = slices.Delete(channel.messages, n, n+1)
+	}
+}
+
+// Messages returns the indexed messages between low and high inclusive, oldest first. It reports false if the
+// channel is not indexed or the index is not complete down to low, in which case the channel has to be paged.
+func (i *Index) Messages(channelID snowflake.ID, low snowflake.ID, high snowflake.ID) ([]IndexedMessage, bool) {
+	if i == nil {
+		return nil, false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	channel, ok := i.channels[channelID]
+	if !ok || low < channel.since {
+		return nil, false
+	}
+	from, _ := slices.BinarySearchFunc(channel.messages, low, compareIndexed)
+	to, found := slices.BinarySearchFunc(channel.messages, high, compareIndexed)
+	if found {
+		to++
+	}
+	return slices.Clone(channel.messages[from:to]), true
+}
+
+// Execute purges the job like Execute does, but takes the messages from the index as far as it covers the job's
+// range instead of paging the channel. The rest of a backwards job is paged from the oldest indexed message on.
+func (i *Index) Execute(ctx context.Context, client rest.Rest, job Job, progress ProgressFunc) (int, error) {
+	low, high := min(job.StartID, job.EndID), max(job.StartID, job.EndID)
+	since, ok := i.Since(job.ChannelID)
+	if !ok || high < since || (job.Forwards && low < since) {
+		return Execute(ctx, client, job, progress)
+	}
+	messages, ok := i.Messages(job.ChannelID, max(low, since), high)
+	if !ok {
+		return Execute(ctx, client, job, progress)
+	}
+	if !job.Forwards {
+		slices.Reverse(messages)
+	}
+	bulkLimit := job.BulkLimit
+	if bulkLimit == 0 {
+		bulkLimit = 100
+	}
+	var (
+		total int
+		batch int
+		done  bool
+	)
+	for len(messages) > 0 && !done {
+		page := messages[:min(bulkLimit, len(messages))]
+		messages = messages[len(page):]
+		messageIDs := make([]snowflake.ID, 0, len(page))
+		for _, indexed := range page {
+			if indexed.ID == job.StartID {
+				continue
+			}
+			if job.MaxCount > 0 && total+len(messageIDs) >= job.MaxCount {
+				done = true
+				break
+			}
+			if !job.Kept(indexed.message(job.ChannelID)) {
+				messageIDs = append(messageIDs, indexed.ID)
+			}
+		}
+		if err := DeleteMessages(ctx, client, job.ChannelID, messageIDs, job.Reason); err != nil {
+			return total, fmt.Errorf("failed to delete messages: %w", err)
+		}
+		for _, messageID := range messageIDs {
+			i.Remove(job.ChannelID, messageID)
+		}
+		metrics.MessagesDeleted.Add(float64(len(messageIDs)))
+		if job.Deleted != nil && len(messageIDs) > 0 {
+			job.Deleted(messageIDs)
+		}
+		total += len(messageIDs)
+		batch++
+		if progress != nil {
+			if err := progress(batch, total); err != nil {
+				return total, err
+			}
+		}
+	}
+	if done || job.Forwards || low >= since || (job.MaxCount > 0 && total >= job.MaxCount) {
+		return total, nil
+	}
+	// the index does not reach the end of the range, page the rest which is older than the oldest indexed message
+	older := job
+	older.StartID = since
+	if job.MaxCount > 0 {
+		older.MaxCount = job.MaxCount - total
+	}
+	var olderProgress ProgressFunc
+	if progress != nil {
+		olderProgress = func(olderBatch int, olderTotal int) error {
+			return progress(batch+olderBatch, total+olderTotal)
+		}
+	}
+	olderTotal, err := Execute(ctx, client, older, olderProgress)
+	return total + olderTotal, err
+}
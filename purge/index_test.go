@@ -0,0 +1,121 @@
+package purge
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// indexEvent is a gateway event fed to an index, a message without a channel resets the index since its ID.
+type indexEvent struct {
+	message discord.Message
+	remove  bool
+}
+
+func putEvent(channelID snowflake.ID, messageID snowflake.ID) indexEvent {
+	return indexEvent{message: discord.Message{ID: messageID, ChannelID: channelID}}
+}
+
+func removeEvent(channelID snowflake.ID, messageID snowflake.ID) indexEvent {
+	return indexEvent{message: discord.Message{ID: messageID, ChannelID: channelID}, remove: true}
+}
+
+func resetEvent(since snowflake.ID) indexEvent {
+	return indexEvent{message: discord.Message{ID: since}}
+}
+
+func TestIndexEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []indexEvent
+		want   []snowflake.ID
+		since  snowflake.ID
+	}{
+		{"created messages", []indexEvent{putEvent(1, 30), putEvent(1, 10), putEvent(1, 20)}, []snowflake.ID{10, 20, 30}, 0},
+		{"updated message", []indexEvent{putEvent(1, 10), putEvent(1, 10)}, []snowflake.ID{10}, 0},
+		{"deleted message", []indexEvent{putEvent(1, 10), putEvent(1, 20), removeEvent(1, 10)}, []snowflake.ID{20}, 0},
+		{"deleted unknown message", []indexEvent{putEvent(1, 10), removeEvent(1, 20)}, []snowflake.ID{10}, 0},
+		{"other channel", []indexEvent{putEvent(1, 10), putEvent(2, 20), removeEvent(2, 10)}, []snowflake.ID{10}, 0},
+		{"over the limit", []indexEvent{putEvent(1, 10), putEvent(1, 20), putEvent(1, 30), putEvent(1, 40)}, []snowflake.ID{20, 30, 40}, 20},
+		{"reset", []indexEvent{putEvent(1, 10), resetEvent(15), putEvent(1, 20)}, []snowflake.ID{20}, 15},
+		{"older than reset", []indexEvent{resetEvent(15), putEvent(1, 10), putEvent(1, 20)}, []snowflake.ID{20}, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewIndex([]snowflake.ID{1}, 3)
+			for _, event := range tt.events {
+				switch {
+				case event.message.ChannelID == 0:
+					index.Reset(event.message.ID)
+				case event.remove:
+					index.Remove(event.message.ChannelID, event.message.ID)
+				default:
+					index.Put(event.message)
+				}
+			}
+			messages, ok := index.Messages(1, tt.since, 100)
+			if !ok {
+				t.Fatalf("index is not complete from %d", tt.since)
+			}
+			ids := make([]snowflake.ID, len(messages))
+			for i, message := range messages {
+				ids[i] = message.ID
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("indexed messages = %v, want %v", ids, tt.want)
+			}
+			if since, _ := index.Since(1); since != tt.since {
+				t.Errorf("index is complete since %d, want %d", since, tt.since)
+			}
+		})
+	}
+}
+
+func TestIndexMessages(t *testing.T) {
+	index := NewIndex([]snowflake.ID{1}, 10)
+	index.Reset(5)
+	for _, id := range []snowflake.ID{10, 20, 30, 40} {
+		index.Put(discord.Message{ID: id, ChannelID: 1})
+	}
+	tests := []struct {
+		name      string
+		channelID snowflake.ID
+		low       snowflake.ID
+		high      snowflake.ID
+		want      []snowflake.ID
+		ok        bool
+	}{
+		{"inclusive bounds", 1, 20, 30, []snowflake.ID{20, 30}, true},
+		{"bounds between messages", 1, 15, 35, []snowflake.ID{20, 30}, true},
+		{"complete from since", 1, 5, 100, []snowflake.ID{10, 20, 30, 40}, true},
+		{"older than since", 1, 4, 100, nil, false},
+		{"not indexed", 2, 10, 40, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, ok := index.Messages(tt.channelID, tt.low, tt.high)
+			if ok != tt.ok {
+				t.Fatalf("Messages reports %t, want %t", ok, tt.ok)
+			}
+			var ids []snowflake.ID
+			for _, message := range messages {
+				ids = append(ids, message.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("messages = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilIndex(t *testing.T) {
+	var index *Index
+	if index.Indexes(1) {
+		t.Error("nil index indexes a channel")
+	}
+	if _, ok := index.Messages(1, 0, 100); ok {
+		t.Error("nil index returns messages")
+	}
+}
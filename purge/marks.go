@@ -0,0 +1,70 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Marks are the emojis moderators react with to mark messages in a purge's range to purge or to keep. Custom emojis
+// are written as name:id.
+type Marks struct {
+	Purge string
+	Keep  string
+}
+
+// CollectMarks scans the job's range for messages the user has reacted to with one of the marks' emojis and returns
+// the IDs of the messages marked to purge and to keep.
+func CollectMarks(ctx context.Context, client rest.Rest, job Job, userID snowflake.ID, marks Marks) ([]snowflake.ID, []snowflake.ID, error) {
+	var purgeIDs, keepIDs []snowflake.ID
+	err := Scan(ctx, client, job, func(messages []discord.Message) error {
+		for _, message := range messages {
+			keep, err := reacted(ctx, client, message, marks.Keep, userID)
+			if err != nil {
+				return err
+			}
+			if keep {
+				keepIDs = append(keepIDs, message.ID)
+				continue
+			}
+			marked, err := reacted(ctx, client, message, marks.Purge, userID)
+			if err != nil {
+				return err
+			}
+			if marked {
+				purgeIDs = append(purgeIDs, message.ID)
+			}
+		}
+		return nil
+	})
+	return purgeIDs, keepIDs, err
+}
+
+// reacted reports whether the user has reacted to the message with the emoji.
+func reacted(ctx context.Context, client rest.Rest, message discord.Message, emoji string, userID snowflake.ID) (bool, error) {
+	if !slices.ContainsFunc(message.Reactions, func(reaction discord.MessageReaction) bool {
+		return reaction.Emoji.Reaction() == emoji
+	}) {
+		return false, nil
+	}
+	var after int
+	for {
+		users, err := client.GetReactions(message.ChannelID, message.ID, emoji, discord.MessageReactionTypeNormal, after, 100, rest.WithCtx(ctx))
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch reactions: %w", err)
+		}
+		if slices.ContainsFunc(users, func(user discord.User) bool {
+			return user.ID == userID
+		}) {
+			return true, nil
+		}
+		if len(users) < 100 {
+			return false, nil
+		}
+		after = int(users[len(users)-1].ID)
+	}
+}
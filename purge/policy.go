@@ -0,0 +1,123 @@
+package purge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+type Mode string
+
+const (
+	ModeSimple    Mode = "simple"
+	ModeAdvanced  Mode = "advanced"
+	ModeRaid      Mode = "raid"
+	ModeUser      Mode = "user"
+	ModeThread    Mode = "thread"
+	ModeForum     Mode = "forum"
+	ModeSchedule  Mode = "schedule"
+	ModeRetention Mode = "retention"
+	ModeReactions Mode = "reactions"
+)
+
+// Policy grants members holding RoleID access to purges. Empty Modes or Channels allow every mode or channel,
+// a MaxCount of 0 means the amount of purged messages is not limited. Senior members may take over purge setups of others.
+type Policy struct {
+	Name     string         `json:"name"`
+	RoleID   snowflake.ID   `json:"role_id"`
+	Modes    []Mode         `json:"modes"`
+	MaxCount int            `json:"max_count"`
+	Channels []snowflake.ID `json:"channels"`
+	Senior   bool           `json:"senior"`
+}
+
+// Policies maps guild IDs to their role policies. Guilds without any policies only rely on Discord permissions.
+type Policies map[snowflake.ID][]Policy
+
+func LoadPolicies(path string) (Policies, error) {
+	policies := make(Policies)
+	if path == "" {
+		return policies, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse policies: %w", err)
+	}
+	return policies, nil
+}
+
+// PolicyError is returned if the policies deny a purge. Reason is the catalog key of the reason which is formatted
+// with Args, so it can be described in the member's language.
+type PolicyError struct {
+	Policy string
+	Reason string
+	Args   []any
+}
+
+func (e *PolicyError) Error() string {
+	reason := e.Reason
+	if len(e.Args) > 0 {
+		reason = fmt.Sprintf("%s %v", e.Reason, e.Args)
+	}
+	if e.Policy == "" {
+		return reason
+	}
+	return fmt.Sprintf("policy %s blocked this: %s", e.Policy, reason)
+}
+
+// Authorize checks whether any of the given roles may purge count messages in the channel using mode.
+// An empty mode or a count of 0 skip the respective checks. The returned count is the highest amount of messages
+// the matching policies allow to purge, 0 meaning unlimited.
+func (p Policies) Authorize(guildID, channelID snowflake.ID, roleIDs []snowflake.ID, mode Mode, count int) (int, error) {
+	policies := p[guildID]
+	if len(policies) == 0 {
+		return 0, nil
+	}
+	var (
+		granted  bool
+		maxCount int
+		errs     []error
+	)
+	for _, policy := range policies {
+		if policy.RoleID != guildID && !slices.Contains(roleIDs, policy.RoleID) {
+			continue
+		}
+		if len(policy.Channels) > 0 && !slices.Contains(policy.Channels, channelID) {
+			errs = append(errs, &PolicyError{Policy: policy.Name, Reason: "policy.channel"})
+			continue
+		}
+		if mode != "" && len(policy.Modes) > 0 && !slices.Contains(policy.Modes, mode) {
+			errs = append(errs, &PolicyError{Policy: policy.Name, Reason: "policy.mode", Args: []any{string(mode)}})
+			continue
+		}
+		if count > 0 && policy.MaxCount > 0 && count > policy.MaxCount {
+			errs = append(errs, &PolicyError{Policy: policy.Name, Reason: "policy.max_count", Args: []any{policy.MaxCount}})
+			continue
+		}
+		if !granted || (maxCount != 0 && (policy.MaxCount == 0 || policy.MaxCount > maxCount)) {
+			maxCount = policy.MaxCount
+		}
+		granted = true
+	}
+	if granted {
+		return maxCount, nil
+	}
+	if len(errs) == 0 {
+		return 0, &PolicyError{Reason: "policy.no_role"}
+	}
+	return 0, errors.Join(errs...)
+}
+
+// Senior reports whether any of the given roles has a senior policy in the guild.
+func (p Policies) Senior(guildID snowflake.ID, roleIDs []snowflake.ID) bool {
+	return slices.ContainsFunc(p[guildID], func(policy Policy) bool {
+		return policy.Senior && (policy.RoleID == guildID || slices.Contains(roleIDs, policy.RoleID))
+	})
+}
@@ -0,0 +1,93 @@
+package purge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func TestPoliciesAuthorize(t *testing.T) {
+	const (
+		guildID     snowflake.ID = 1
+		channelID   snowflake.ID = 10
+		otherID     snowflake.ID = 11
+		modRole     snowflake.ID = 100
+		helperRole  snowflake.ID = 101
+		trustedRole snowflake.ID = 102
+	)
+	policies := Policies{
+		guildID: {
+			{Name: "mods", RoleID: modRole, MaxCount: 500},
+			{Name: "helpers", RoleID: helperRole, Modes: []Mode{ModeSimple}, MaxCount: 50, Channels: []snowflake.ID{channelID}},
+			{Name: "trusted", RoleID: trustedRole},
+		},
+	}
+	tests := []struct {
+		name      string
+		guildID   snowflake.ID
+		channelID snowflake.ID
+		roleIDs   []snowflake.ID
+		mode      Mode
+		count     int
+		wantMax   int
+		wantErr   string
+	}{
+		{name: "guild without policies", guildID: 2, roleIDs: nil, mode: ModeRaid, count: 10_000},
+		{name: "no matching role", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{999}, wantErr: "policy.no_role"},
+		{name: "limited role", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{modRole}, mode: ModeAdvanced, wantMax: 500},
+		{name: "over the limit", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{modRole}, count: 501, wantErr: "policy mods blocked this: policy.max_count [500]"},
+		{name: "disallowed mode", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{helperRole}, mode: ModeAdvanced, wantErr: "policy.mode [advanced]"},
+		{name: "disallowed channel", guildID: guildID, channelID: otherID, roleIDs: []snowflake.ID{helperRole}, mode: ModeSimple, wantErr: "policy.channel"},
+		{name: "allowed mode and channel", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{helperRole}, mode: ModeSimple, count: 50, wantMax: 50},
+		{name: "highest limit wins", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{helperRole, modRole}, mode: ModeSimple, wantMax: 500},
+		{name: "unlimited wins", guildID: guildID, channelID: channelID, roleIDs: []snowflake.ID{modRole, trustedRole}, count: 1000, wantMax: 0},
+		{name: "other policy allows", guildID: guildID, channelID: otherID, roleIDs: []snowflake.ID{helperRole, modRole}, mode: ModeSimple, wantMax: 500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxCount, err := policies.Authorize(test.guildID, test.channelID, test.roleIDs, test.mode, test.count)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Authorize = %d, %v, want an error containing %q", maxCount, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authorize = %v, want no error", err)
+			}
+			if maxCount != test.wantMax {
+				t.Errorf("Authorize = %d, want a max count of %d", maxCount, test.wantMax)
+			}
+		})
+	}
+}
+
+func TestPoliciesEveryoneRole(t *testing.T) {
+	const guildID snowflake.ID = 1
+	policies := Policies{
+		guildID: {{Name: "everyone", RoleID: guildID, Modes: []Mode{ModeSimple}, MaxCount: 10}},
+	}
+	if maxCount, err := policies.Authorize(guildID, 10, nil, ModeSimple, 5); err != nil || maxCount != 10 {
+		t.Errorf("Authorize = %d, %v, want the everyone policy to apply to members without roles", maxCount, err)
+	}
+}
+
+func TestPoliciesSenior(t *testing.T) {
+	const guildID snowflake.ID = 1
+	policies := Policies{
+		guildID: {
+			{Name: "mods", RoleID: 100},
+			{Name: "admins", RoleID: 101, Senior: true},
+		},
+	}
+	if policies.Senior(guildID, []snowflake.ID{100}) {
+		t.Error("a role without a senior policy is senior")
+	}
+	if !policies.Senior(guildID, []snowflake.ID{100, 101}) {
+		t.Error("a role with a senior policy is not senior")
+	}
+	if policies.Senior(2, []snowflake.ID{101}) {
+		t.Error("a role is senior in another guild")
+	}
+}
@@ -1,31 +1,148 @@
 package purge
 
 import (
+	"fmt"
+	"log/slog"
+	"maps"
 	"slices"
+	"time"
 
+	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/snowflake/v2"
 )
 
 type Purge struct {
-	UserID snowflake.ID
+	GuildID     snowflake.ID
+	ChannelID   snowflake.ID
+	ChannelType discord.ChannelType
+	UserID      snowflake.ID
+	PromptID    snowflake.ID
+	// CorrelationID identifies the purge in the logs and records from its setup on.
+	CorrelationID snowflake.ID
 
+	Mode      Mode
+	MaxCount  int
 	BulkLimit int
 	StartID   snowflake.ID
 	EndID     snowflake.ID
 
 	Forwards bool
 
-	exclude []snowflake.ID
-	include []snowflake.ID
+	// Reactions selects the reactions to remove in reaction purges.
+	Reactions ReactionFilter
+	// Approval is set once a second moderator has been asked to approve the purge.
+	Approval *Approval
 
-	Running bool
+	// excluded holds the single excluded messages, included the messages kept out of excluded ranges and filters.
+	excluded     map[snowflake.ID]struct{}
+	included     map[snowflake.ID]struct{}
+	ranges       []ExcludedRange
+	filters      []ExclusionFilter
+	nextFilterID int
+
+	Running      bool
+	lastActivity time.Time
+}
+
+// Record prepares the record of running the purge, which shares the correlation ID of the setup.
+func (p *Purge) Record() *Record {
+	record := NewRecord(p.GuildID, p.ChannelID, p.UserID, p.Mode)
+	record.CorrelationID = p.CorrelationID
+	if p.Approval != nil {
+		record.ApproverID = p.Approval.ApproverID
+	}
+	return record
+}
+
+// Logger returns the logger of the purge's record.
+func (p *Purge) Logger() *slog.Logger {
+	return p.Record().Logger()
+}
+
+// Excluded returns the exclusion of the purge composed of its excluded messages, ranges and filters. Included
+// messages are never excluded.
+func (p *Purge) Excluded() Exclusion {
+	exclusion := ExcludeMessages(p.ExcludedMessages()...)
+	for _, r := range p.ranges {
+		exclusion = exclusion.Or(ExcludeRange(r.Low, r.High))
+	}
+	for _, filter := range p.filters {
+		exclusion = exclusion.Or(filter.Exclude)
+	}
+	included := maps.Clone(p.included)
+	return func(message discord.Message) bool {
+		if _, ok := included[message.ID]; ok {
+			return false
+		}
+		return exclusion(message)
+	}
+}
+
+// ExcludedMessages returns the single excluded messages, oldest first.
+func (p *Purge) ExcludedMessages() []snowflake.ID {
+	return slices.Sorted(maps.Keys(p.excluded))
+}
+
+// ExcludedRanges returns the excluded sub-ranges.
+func (p *Purge) ExcludedRanges() []ExcludedRange {
+	return slices.Clone(p.ranges)
+}
+
+// ExclusionFilters returns the filters excluding messages.
+func (p *Purge) ExclusionFilters() []ExclusionFilter {
+	return slices.Clone(p.filters)
+}
+
+// Exclusions returns the stored form of the purge's exclusions.
+func (p *Purge) Exclusions() Exclusions {
+	return Exclusions{
+		MessageIDs:  p.ExcludedMessages(),
+		IncludedIDs: slices.Sorted(maps.Keys(p.included)),
+		Ranges:      p.ExcludedRanges(),
+		Filters:     p.ExclusionFilters(),
+	}
+}
+
+// HasExclusions reports whether the purge excludes any messages.
+func (p *Purge) HasExclusions() bool {
+	return len(p.excluded) > 0 || len(p.ranges) > 0 || len(p.filters) > 0
+}
+
+// Range returns the lowest and the highest message ID of the purge regardless of its direction.
+func (p *Purge) Range() (snowflake.ID, snowflake.ID) {
+	return min(p.StartID, p.EndID), max(p.StartID, p.EndID)
+}
+
+// Overlaps reports whether both purges have their range set and the ranges overlap.
+func (p *Purge) Overlaps(other *Purge) bool {
+	if p.StartID == 0 || p.EndID == 0 || other.StartID == 0 || other.EndID == 0 {
+		return false
+	}
+	low, high := p.Range()
+	otherLow, otherHigh := other.Range()
+	return low <= otherHigh && otherLow <= high
+}
+
+// Job returns the job purging the purge's range without its excluded messages.
+func (p *Purge) Job() Job {
+	return Job{
+		ChannelID:  p.ChannelID,
+		StartID:    p.StartID,
+		EndID:      p.EndID,
+		Forwards:   p.Forwards,
+		BulkLimit:  p.BulkLimit,
+		MaxCount:   p.MaxCount,
+		Keep:       p.Excluded(),
+		Exclusions: p.Exclusions(),
+		Reason:     p.Reason(),
+	}
 }
 
-func (p *Purge) Excluded() []snowflake.ID {
-	if len(p.include) == 0 {
-		return p.exclude
+// Reason returns the audit log reason of the purge's deletions naming its owner and approver.
+func (p *Purge) Reason() string {
+	reason := fmt.Sprintf("Purge by user %d", p.UserID)
+	if p.Approval != nil && p.Approval.ApproverID != 0 {
+		reason += fmt.Sprintf(", approved by user %d", p.Approval.ApproverID)
 	}
-	return slices.DeleteFunc(p.exclude, func(id snowflake.ID) bool {
-		return slices.Contains(p.include, id)
-	})
+	return reason
 }
@@ -0,0 +1,96 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var errMaxCountReached = errors.New("max count reached")
+
+// ReactionFilter selects the reactions a reaction purge removes. An empty Emoji matches every emoji and a zero
+// UserID matches every user.
+type ReactionFilter struct {
+	Emoji  string       `json:"emoji,omitempty"`
+	UserID snowflake.ID `json:"user_id,omitempty"`
+}
+
+// Execute removes the filter's reactions from the messages in the job's range instead of purging them. Progress is
+// reported per page of messages and the amount of messages reactions were removed from is returned even if the
+// execution fails. It stops once reactions were removed from the job's max count of messages.
+func (f ReactionFilter) Execute(ctx context.Context, client rest.Rest, job Job, progress ProgressFunc) (int, error) {
+	var (
+		batch int
+		total int
+	)
+	err := Scan(ctx, client, job, func(messages []discord.Message) error {
+		for _, message := range messages {
+			if job.Kept(message) {
+				continue
+			}
+			removed, err := f.remove(ctx, client, message)
+			if removed {
+				total++
+			}
+			if err != nil {
+				return fmt.Errorf("failed to remove reactions: %w", err)
+			}
+			if job.MaxCount > 0 && total >= job.MaxCount {
+				break
+			}
+		}
+		batch++
+		if progress != nil {
+			if err := progress(batch, total); err != nil {
+				return err
+			}
+		}
+		if job.MaxCount > 0 && total >= job.MaxCount {
+			return errMaxCountReached
+		}
+		return nil
+	})
+	if errors.Is(err, errMaxCountReached) {
+		err = nil
+	}
+	return total, err
+}
+
+// remove removes the filter's reactions from the message and reports whether any were removed. Only the emojis the
+// filter's user reacted with are removed for them.
+func (f ReactionFilter) remove(ctx context.Context, client rest.Rest, message discord.Message) (bool, error) {
+	var emojis []string
+	for _, reaction := range message.Reactions {
+		if emoji := reaction.Emoji.Reaction(); f.Emoji == "" || emoji == f.Emoji {
+			emojis = append(emojis, emoji)
+		}
+	}
+	switch {
+	case len(emojis) == 0:
+		return false, nil
+	case f.UserID != 0:
+		var removed bool
+		for _, emoji := range emojis {
+			ok, err := reacted(ctx, client, message, emoji, f.UserID)
+			if err != nil {
+				return removed, err
+			}
+			if !ok {
+				continue
+			}
+			if err := client.RemoveUserReaction(message.ChannelID, message.ID, emoji, f.UserID, rest.WithCtx(ctx)); err != nil {
+				return removed, err
+			}
+			removed = true
+		}
+		return removed, nil
+	case f.Emoji != "":
+		return true, client.RemoveAllReactionsForEmoji(message.ChannelID, message.ID, f.Emoji, rest.WithCtx(ctx))
+	default:
+		return true, client.RemoveAllReactions(message.ChannelID, message.ID, rest.WithCtx(ctx))
+	}
+}
@@ -0,0 +1,108 @@
+package purge
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Record is the audit record of a purge run in a channel. The records of a purge run in multiple channels or
+// resumed after a restart share their correlation ID.
+type Record struct {
+	ID            snowflake.ID   `json:"id"`
+	CorrelationID snowflake.ID   `json:"correlation_id"`
+	GuildID       snowflake.ID   `json:"guild_id"`
+	ChannelID     snowflake.ID   `json:"channel_id"`
+	UserID        snowflake.ID   `json:"user_id"`
+	ApproverID    snowflake.ID   `json:"approver_id,omitempty"`
+	Mode          Mode           `json:"mode"`
+	StartID       snowflake.ID   `json:"start_id,omitempty"`
+	EndID         snowflake.ID   `json:"end_id,omitempty"`
+	Filters       []string       `json:"filters,omitempty"`
+	Count         int            `json:"count"`
+	StartedAt     time.Time      `json:"started_at"`
+	Duration      time.Duration  `json:"duration"`
+	Error         string         `json:"error,omitempty"`
+	MessageIDs    []snowflake.ID `json:"message_ids,omitempty"`
+}
+
+// recordSequence makes the IDs of records started within the same millisecond unique.
+var recordSequence atomic.Uint32
+
+// NewRecord prepares the record of a purge run by the user in the channel, Track fills in the job's details. The
+// channel is 0 for purges run in multiple channels.
+func NewRecord(guildID snowflake.ID, channelID snowflake.ID, userID snowflake.ID, mode Mode) *Record {
+	return &Record{
+		CorrelationID: newRecordID(time.Now()),
+		GuildID:       guildID,
+		ChannelID:     channelID,
+		UserID:        userID,
+		Mode:          mode,
+	}
+}
+
+// newRecordID returns a unique ID for the time.
+func newRecordID(t time.Time) snowflake.ID {
+	return snowflake.New(t) | snowflake.ID(recordSequence.Add(1)&0xfff)
+}
+
+// Logger returns the logger of the purge, its lines carry the correlation ID, the guild, channel, user and mode.
+func (r *Record) Logger() *slog.Logger {
+	attrs := []any{
+		slog.Any("purge.id", r.CorrelationID),
+		slog.Any("guild.id", r.GuildID),
+	}
+	if r.ChannelID != 0 {
+		attrs = append(attrs, slog.Any("channel.id", r.ChannelID))
+	}
+	return slog.With(append(attrs,
+		slog.Any("user.id", r.UserID),
+		slog.String("purge.mode", string(r.Mode)),
+	)...)
+}
+
+// Track starts the record of running the job and returns a copy of the job which adds the IDs of its deleted
+// messages to the record.
+func (r *Record) Track(job Job) Job {
+	now := time.Now()
+	r.ID = newRecordID(now)
+	r.ChannelID = job.ChannelID
+	r.StartID = job.StartID
+	r.EndID = job.EndID
+	r.StartedAt = now
+
+	deleted := job.Deleted
+	job.Deleted = func(messageIDs []snowflake.ID) {
+		r.MessageIDs = append(r.MessageIDs, messageIDs...)
+		if deleted != nil {
+			deleted(messageIDs)
+		}
+	}
+	return job
+}
+
+// Finish completes the record with the result of the run.
+func (r *Record) Finish(count int, err error) {
+	r.Count = count
+	r.Duration = time.Since(r.StartedAt)
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// RecordFilter selects records in a guild, zero fields match every record.
+type RecordFilter struct {
+	GuildID   snowflake.ID
+	UserID    snowflake.ID
+	ChannelID snowflake.ID
+	Since     time.Time
+}
+
+func (f RecordFilter) Match(record Record) bool {
+	return record.GuildID == f.GuildID &&
+		(f.UserID == 0 || record.UserID == f.UserID || record.ApproverID == f.UserID) &&
+		(f.ChannelID == 0 || record.ChannelID == f.ChannelID) &&
+		!record.StartedAt.Before(f.Since)
+}
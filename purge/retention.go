@@ -0,0 +1,49 @@
+package purge
+
+import (
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Retention is a recurring rule which purges messages of a channel older than MaxAge or beyond the newest MaxCount
+// messages. Either limit may be 0 to disable it. Pinned messages are always retained.
+type Retention struct {
+	GuildID   snowflake.ID  `json:"guild_id"`
+	ChannelID snowflake.ID  `json:"channel_id"`
+	UserID    snowflake.ID  `json:"user_id"`
+	MaxAge    time.Duration `json:"max_age,omitempty"`
+	MaxCount  int           `json:"max_count,omitempty"`
+	Every     time.Duration `json:"every"`
+	LastRun   time.Time     `json:"last_run"`
+}
+
+// Due reports whether the retention should be enforced at the given time.
+func (r Retention) Due(now time.Time) bool {
+	return !r.LastRun.Add(r.Every).After(now)
+}
+
+// Job returns the job enforcing the retention at the given time. It walks the whole channel history backwards.
+func (r Retention) Job(now time.Time) Job {
+	job := Job{
+		ChannelID: r.ChannelID,
+		StartID:   snowflake.New(now),
+	}
+	if r.MaxCount == 0 {
+		// nothing newer than the max age has to be counted, so skip it entirely
+		job.StartID = snowflake.New(now.Add(-r.MaxAge))
+	}
+	var seen int
+	job.Keep = func(message discord.Message) bool {
+		if message.Pinned {
+			return true
+		}
+		seen++
+		if r.MaxCount > 0 && seen > r.MaxCount {
+			return false
+		}
+		return r.MaxAge == 0 || now.Sub(message.CreatedAt) < r.MaxAge
+	}
+	return job
+}
@@ -0,0 +1,83 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func TestRetentionKeep(t *testing.T) {
+	now := time.Now()
+	// the messages are passed to Keep from the newest to the oldest like a backwards purge does
+	messages := []discord.Message{
+		{CreatedAt: now.Add(-time.Minute)},
+		{CreatedAt: now.Add(-2 * time.Minute), Pinned: true},
+		{CreatedAt: now.Add(-time.Hour)},
+		{CreatedAt: now.Add(-3 * time.Hour)},
+		{CreatedAt: now.Add(-5 * time.Hour), Pinned: true},
+		{CreatedAt: now.Add(-6 * time.Hour)},
+	}
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []bool
+	}{
+		{"max count", Retention{MaxCount: 2}, []bool{true, true, true, false, true, false}},
+		{"max age", Retention{MaxAge: 2 * time.Hour}, []bool{true, true, true, false, true, false}},
+		{"max count within max age", Retention{MaxCount: 1, MaxAge: 2 * time.Hour}, []bool{true, true, false, false, true, false}},
+		{"max age within max count", Retention{MaxCount: 3, MaxAge: 2 * time.Hour}, []bool{true, true, true, false, true, false}},
+		{"more than all messages", Retention{MaxCount: 10}, []bool{true, true, true, true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep := tt.retention.Job(now).Keep
+			for i, message := range messages {
+				if got := keep(message); got != tt.want[i] {
+					t.Errorf("keep(message %d) = %t, want %t", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetentionJobStart(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		retention Retention
+		start     snowflake.ID
+	}{
+		{"max count", Retention{MaxCount: 10, MaxAge: time.Hour}, snowflake.New(now)},
+		{"only max age", Retention{MaxAge: time.Hour}, snowflake.New(now.Add(-time.Hour))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if job := tt.retention.Job(now); job.StartID != tt.start {
+				t.Errorf("job starts at %d, want %d", job.StartID, tt.start)
+			}
+		})
+	}
+}
+
+func TestRetentionDue(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		want    bool
+	}{
+		{"never run", time.Time{}, true},
+		{"interval passed", now.Add(-time.Hour), true},
+		{"interval not passed", now.Add(-59 * time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retention := Retention{Every: time.Hour, LastRun: tt.lastRun}
+			if got := retention.Due(now); got != tt.want {
+				t.Errorf("Due = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
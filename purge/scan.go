@@ -0,0 +1,46 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+)
+
+// Scan pages through the job's range like Execute does without purging anything and passes the messages of each page
+// which are within the range to fn. Returning an error from fn stops the scan.
+func Scan(ctx context.Context, client rest.Rest, job Job, fn func(messages []discord.Message) error) error {
+	page := client.GetMessagesPage(job.ChannelID, job.StartID, 100, rest.WithCtx(ctx))
+	pageFunc := page.Previous
+	if job.Forwards {
+		pageFunc = page.Next
+	}
+	for {
+		if !pageFunc() {
+			if errors.Is(page.Err, rest.ErrNoMorePages) {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch messages: %w", page.Err)
+		}
+		var done bool
+		messages := make([]discord.Message, 0, len(page.Items))
+		for _, message := range page.Items {
+			if (job.Forwards && message.ID > job.EndID) || (!job.Forwards && message.ID < job.EndID) {
+				done = true
+				continue
+			}
+			if message.ID == job.EndID {
+				done = true
+			}
+			messages = append(messages, message)
+		}
+		if err := fn(messages); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
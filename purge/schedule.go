@@ -0,0 +1,41 @@
+package purge
+
+import (
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// Schedule is a purge job which runs once at a given time. It either purges the messages posted within Window
+// before the run or the range between StartID and EndID, optionally only messages of the given authors. MaxCount limits
+// the amount of purged messages, 0 meaning unlimited.
+type Schedule struct {
+	ID        snowflake.ID   `json:"id"`
+	GuildID   snowflake.ID   `json:"guild_id"`
+	ChannelID snowflake.ID   `json:"channel_id"`
+	UserID    snowflake.ID   `json:"user_id"`
+	At        time.Time      `json:"at"`
+	Window    time.Duration  `json:"window,omitempty"`
+	StartID   snowflake.ID   `json:"start_id,omitempty"`
+	EndID     snowflake.ID   `json:"end_id,omitempty"`
+	AuthorIDs []snowflake.ID `json:"author_ids,omitempty"`
+	MaxCount  int            `json:"max_count,omitempty"`
+}
+
+// Job returns the job to run for the schedule at the given time.
+func (s Schedule) Job(now time.Time) Job {
+	job := Job{
+		ChannelID: s.ChannelID,
+		StartID:   s.StartID,
+		EndID:     s.EndID,
+		Forwards:  s.EndID > s.StartID,
+		AuthorIDs: s.AuthorIDs,
+		MaxCount:  s.MaxCount,
+	}
+	if s.Window > 0 {
+		job.StartID = snowflake.New(now)
+		job.EndID = snowflake.New(now.Add(-s.Window))
+		job.Forwards = false
+	}
+	return job
+}
@@ -0,0 +1,38 @@
+package purge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func TestScheduleJob(t *testing.T) {
+	now := time.Now()
+	older, newer := snowflake.New(now.Add(-2*time.Hour)), snowflake.New(now.Add(-time.Hour))
+	tests := []struct {
+		name     string
+		schedule Schedule
+		start    snowflake.ID
+		end      snowflake.ID
+		forwards bool
+	}{
+		{"window", Schedule{Window: 30 * time.Minute, StartID: older, EndID: newer}, snowflake.New(now), snowflake.New(now.Add(-30 * time.Minute)), false},
+		{"backwards range", Schedule{StartID: newer, EndID: older}, newer, older, false},
+		{"forwards range", Schedule{StartID: older, EndID: newer}, older, newer, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.schedule.ChannelID = 10
+			tt.schedule.AuthorIDs = []snowflake.ID{20}
+			tt.schedule.MaxCount = 50
+			job := tt.schedule.Job(now)
+			if job.StartID != tt.start || job.EndID != tt.end || job.Forwards != tt.forwards {
+				t.Errorf("job runs from %d to %d forwards %t, want from %d to %d forwards %t", job.StartID, job.EndID, job.Forwards, tt.start, tt.end, tt.forwards)
+			}
+			if job.ChannelID != 10 || len(job.AuthorIDs) != 1 || job.AuthorIDs[0] != 20 || job.MaxCount != 50 {
+				t.Errorf("job = %+v, want the schedule's channel, authors and max count", job)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"advanced-purge/purge"
+	"slices"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var bucketCheckpoints = []byte("checkpoints")
+
+func (s *Store) PutCheckpoint(checkpoint purge.Checkpoint) error {
+	return put(s, bucketCheckpoints, checkpoint.ID, checkpoint)
+}
+
+// Checkpoints returns the checkpoints of the group.
+func (s *Store) Checkpoints(groupID snowflake.ID) ([]purge.Checkpoint, error) {
+	checkpoints, err := all[purge.Checkpoint](s, bucketCheckpoints)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(checkpoints, func(checkpoint purge.Checkpoint) bool {
+		return checkpoint.GroupID != groupID
+	}), nil
+}
+
+func (s *Store) DeleteCheckpoint(id snowflake.ID) error {
+	return remove(s, bucketCheckpoints, id)
+}
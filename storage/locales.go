@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var bucketLocales = []byte("locales")
+
+// PutGuildLocale overrides the locale of the interactions in the guild.
+func (s *Store) PutGuildLocale(guildID snowflake.ID, locale discord.Locale) error {
+	return put(s, bucketLocales, guildID, locale)
+}
+
+// GuildLocale returns the locale override of the guild or nil if there is none.
+func (s *Store) GuildLocale(guildID snowflake.ID) (*discord.Locale, error) {
+	return get[discord.Locale](s, bucketLocales, guildID)
+}
+
+func (s *Store) DeleteGuildLocale(guildID snowflake.ID) error {
+	return remove(s, bucketLocales, guildID)
+}
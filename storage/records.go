@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"advanced-purge/purge"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// bucketRecords holds the records without their message IDs keyed by guild and record ID, so the records of a
+	// guild are sorted by time.
+	bucketRecords = []byte("guild_records")
+	// bucketRecordMessages holds the IDs of the deleted messages of each record.
+	bucketRecordMessages = []byte("record_messages")
+)
+
+// recordKey returns the key of the record in bucketRecords, the guild ID followed by the record ID in big endian.
+func recordKey(guildID snowflake.ID, recordID snowflake.ID) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(guildID))
+	binary.BigEndian.PutUint64(key[8:], uint64(recordID))
+	return key
+}
+
+func (s *Store) PutRecord(record purge.Record) error {
+	messageIDs := record.MessageIDs
+	record.MessageIDs = nil
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	messagesData, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		records, err := tx.CreateBucketIfNotExists(bucketRecords)
+		if err != nil {
+			return err
+		}
+		messages, err := tx.CreateBucketIfNotExists(bucketRecordMessages)
+		if err != nil {
+			return err
+		}
+		if err := records.Put(recordKey(record.GuildID, record.ID), data); err != nil {
+			return err
+		}
+		return messages.Put([]byte(record.ID.String()), messagesData)
+	})
+}
+
+// Record returns the record of the guild including its message IDs or nil if there is none.
+func (s *Store) Record(guildID snowflake.ID, id snowflake.ID) (*purge.Record, error) {
+	var record *purge.Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		records := tx.Bucket(bucketRecords)
+		if records == nil {
+			return nil
+		}
+		data := records.Get(recordKey(guildID, id))
+		if data == nil {
+			return nil
+		}
+		record = new(purge.Record)
+		if err := json.Unmarshal(data, record); err != nil {
+			return err
+		}
+		if messages := tx.Bucket(bucketRecordMessages); messages != nil {
+			if data := messages.Get([]byte(id.String())); data != nil {
+				return json.Unmarshal(data, &record.MessageIDs)
+			}
+		}
+		return nil
+	})
+	return record, err
+}
+
+// Records returns the records matching the filter without their message IDs, newest first. Only the records of the
+// filter's guild since the filter's time are read.
+func (s *Store) Records(filter purge.RecordFilter) ([]purge.Record, error) {
+	var records []purge.Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketRecords)
+		if b == nil {
+			return nil
+		}
+		prefix := recordKey(filter.GuildID, 0)[:8]
+		var since snowflake.ID
+		if !filter.Since.IsZero() {
+			since = snowflake.New(filter.Since)
+		}
+		c := b.Cursor()
+		for key, data := c.Seek(recordKey(filter.GuildID, since)); key != nil && bytes.HasPrefix(key, prefix); key, data = c.Next() {
+			var record purge.Record
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+			if filter.Match(record) {
+				records = append(records, record)
+			}
+		}
+		return nil
+	})
+	slices.Reverse(records)
+	return records, err
+}
+
+// PruneRecords deletes the records which have been started before the time and returns how many it deleted.
+func (s *Store) PruneRecords(before time.Time) (int, error) {
+	var pruned int
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucketRecords)
+		if b == nil {
+			return nil
+		}
+		var keys [][]byte
+		c := b.Cursor()
+		for key, _ := c.First(); key != nil; {
+			guildID := snowflake.ID(binary.BigEndian.Uint64(key[:8]))
+			if snowflake.ID(binary.BigEndian.Uint64(key[8:])).Time().Before(before) {
+				keys = append(keys, slices.Clone(key))
+				key, _ = c.Next()
+				continue
+			}
+			// the records of a guild are sorted by time, skip to the next guild
+			key, _ = c.Seek(recordKey(guildID+1, 0))
+		}
+		messages := tx.Bucket(bucketRecordMessages)
+		for _, key := range keys {
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+			if messages != nil {
+				recordID := snowflake.ID(binary.BigEndian.Uint64(key[8:]))
+				if err := messages.Delete([]byte(recordID.String())); err != nil {
+					return err
+				}
+			}
+		}
+		pruned = len(keys)
+		return nil
+	})
+	return pruned, err
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"advanced-purge/purge"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+func TestRecords(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	put := func(guildID snowflake.ID, age time.Duration, messageIDs ...snowflake.ID) purge.Record {
+		record := purge.Record{
+			ID:         snowflake.New(now.Add(-age)),
+			GuildID:    guildID,
+			StartedAt:  now.Add(-age),
+			MessageIDs: messageIDs,
+		}
+		if err := store.PutRecord(record); err != nil {
+			t.Fatal(err)
+		}
+		return record
+	}
+	old := put(1, 48*time.Hour, 10, 11)
+	recent := put(1, time.Hour, 12)
+	other := put(2, 72*time.Hour)
+
+	records, err := store.Records(purge.RecordFilter{GuildID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID != recent.ID || records[1].ID != old.ID {
+		t.Fatalf("Records = %v, want the records of guild 1 newest first", records)
+	}
+	if records[1].MessageIDs != nil {
+		t.Errorf("Records loaded the message IDs %v", records[1].MessageIDs)
+	}
+	records, err = store.Records(purge.RecordFilter{GuildID: 1, Since: now.Add(-2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ID != recent.ID {
+		t.Fatalf("Records since 2h = %v, want only the recent record", records)
+	}
+
+	record, err := store.Record(1, old.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || len(record.MessageIDs) != 2 {
+		t.Fatalf("Record = %+v, want the old record with its 2 message IDs", record)
+	}
+	if record, err := store.Record(2, old.ID); err != nil || record != nil {
+		t.Fatalf("Record of another guild = %+v, %v, want nil", record, err)
+	}
+
+	pruned, err := store.PruneRecords(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d records, want 2", pruned)
+	}
+	for _, record := range []purge.Record{old, other} {
+		if got, err := store.Record(record.GuildID, record.ID); err != nil || got != nil {
+			t.Errorf("Record %d after pruning = %+v, %v, want nil", record.ID, got, err)
+		}
+	}
+	if got, err := store.Record(recent.GuildID, recent.ID); err != nil || got == nil {
+		t.Errorf("Record %d after pruning = %+v, %v, want the recent record", recent.ID, got, err)
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"advanced-purge/purge"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var bucketRetentions = []byte("retentions")
+
+func (s *Store) PutRetention(retention purge.Retention) error {
+	return put(s, bucketRetentions, retention.ChannelID, retention)
+}
+
+func (s *Store) Retention(channelID snowflake.ID) (*purge.Retention, error) {
+	return get[purge.Retention](s, bucketRetentions, channelID)
+}
+
+func (s *Store) Retentions() ([]purge.Retention, error) {
+	return all[purge.Retention](s, bucketRetentions)
+}
+
+func (s *Store) DeleteRetention(channelID snowflake.ID) error {
+	return remove(s, bucketRetentions, channelID)
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"advanced-purge/purge"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+var bucketSchedules = []byte("schedules")
+
+func (s *Store) PutSchedule(schedule purge.Schedule) error {
+	return put(s, bucketSchedules, schedule.ID, schedule)
+}
+
+func (s *Store) Schedule(id snowflake.ID) (*purge.Schedule, error) {
+	return get[purge.Schedule](s, bucketSchedules, id)
+}
+
+func (s *Store) Schedules() ([]purge.Schedule, error) {
+	return all[purge.Schedule](s, bucketSchedules)
+}
+
+func (s *Store) DeleteSchedule(id snowflake.ID) error {
+	return remove(s, bucketSchedules, id)
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+	"go.etcd.io/bbolt"
+)
+
+// Store persists the bot's state in an embedded bolt database.
+type Store struct {
+	db *bbolt.DB
+}
+
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func put[T any](s *Store, bucket []byte, id snowflake.ID, v T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(id.String()), data)
+	})
+}
+
+// get returns the value stored under id or nil if there is none.
+func get[T any](s *Store, bucket []byte, id snowflake.ID) (*T, error) {
+	var v *T
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(id.String()))
+		if data == nil {
+			return nil
+		}
+		v = new(T)
+		return json.Unmarshal(data, v)
+	})
+	return v, err
+}
+
+func all[T any](s *Store, bucket []byte) ([]T, error) {
+	var values []T
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return err
+			}
+			values = append(values, v)
+			return nil
+		})
+	})
+	return values, err
+}
+
+func remove(s *Store, bucket []byte, id snowflake.ID) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(id.String()))
+	})
+}